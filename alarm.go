@@ -0,0 +1,150 @@
+package gocal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apognu/gocal/parser"
+)
+
+func (gc *Gocal) parseAlarm(l *Line) error {
+	// If this is nil, that means we did not get a BEGIN:VALARM
+	if gc.alarmBuffer == nil {
+		return nil
+	}
+
+	switch l.Key {
+	case "ACTION":
+		if err := resolve(gc, l, &gc.alarmBuffer.Action, resolveString, nil); err != nil {
+			return err
+		}
+	case "TRIGGER":
+		if err := resolve(gc, l, &gc.alarmBuffer.Trigger, resolveTrigger, nil); err != nil {
+			return err
+		}
+	case "REPEAT":
+		if err := resolve(gc, l, &gc.alarmBuffer.Repeat, resolveInt, nil); err != nil {
+			return err
+		}
+	case "DURATION":
+		if err := resolve(gc, l, &gc.alarmBuffer.Duration, resolveDuration, nil); err != nil {
+			return err
+		}
+	case "SUMMARY":
+		if err := resolve(gc, l, &gc.alarmBuffer.Summary, resolveString, nil); err != nil {
+			return err
+		}
+	case "DESCRIPTION":
+		if err := resolve(gc, l, &gc.alarmBuffer.Description, resolveString, nil); err != nil {
+			return err
+		}
+	case "ATTENDEE":
+		gc.alarmBuffer.Attendees = append(gc.alarmBuffer.Attendees, parseAttendee(l))
+	case "ATTACH":
+		gc.alarmBuffer.Attachments = append(gc.alarmBuffer.Attachments, parseAttachment(l))
+	default:
+		parseCustomAttribute(l, &gc.alarmBuffer.CustomAttributes)
+	}
+
+	return nil
+}
+
+// resolveTrigger reads a TRIGGER property, which is either a duration relative
+// to the event (RELATED=START by default) or an absolute UTC date-time.
+func resolveTrigger(gc *Gocal, l *Line) (Trigger, Trigger, error) {
+	if l.Params["VALUE"] == "DATE-TIME" {
+		d, err := gc.parseTime(l.Value, l.Params, parser.TimeStart, false)
+		if err != nil {
+			return Trigger{}, Trigger{}, fmt.Errorf("could not parse: %w", err)
+		}
+
+		return Trigger{Time: d}, Trigger{}, nil
+	}
+
+	d, err := parser.ParseNominalDuration(l.Value)
+	if err != nil {
+		return Trigger{}, Trigger{}, fmt.Errorf("could not parse: %w", err)
+	}
+
+	related := l.Params["RELATED"]
+	if related == "" {
+		related = "START"
+	}
+
+	exact := d.Duration()
+
+	return Trigger{Duration: &exact, NominalDuration: d, Related: related}, Trigger{}, nil
+}
+
+func (gc *Gocal) checkAlarm() error {
+	if gc.alarmBuffer.Action == "" {
+		gc.alarmBuffer.Valid = false
+		return fmt.Errorf("could not parse alarm without ACTION")
+	}
+	if gc.alarmBuffer.Trigger.Duration == nil && gc.alarmBuffer.Trigger.Time == nil {
+		gc.alarmBuffer.Valid = false
+		return fmt.Errorf("could not parse alarm without TRIGGER")
+	}
+	if (gc.alarmBuffer.Repeat > 0) != (gc.alarmBuffer.Duration != nil) {
+		gc.alarmBuffer.Valid = false
+		return fmt.Errorf("both REPEAT and DURATION must be provided on alarms")
+	}
+
+	return nil
+}
+
+// Triggers computes the instants at which the alarm fires for the given event
+// occurrence, including its repetitions. Relative triggers are computed from
+// the occurrence's own start or end, so this can be called on every instance
+// returned by ExpandRecurringEvent. Their days and weeks are nominal, so that a
+// -P1D trigger fires at the same time on the day before, whatever the DST
+// changes in between.
+func (a Alarm) Triggers(e Event) []time.Time {
+	var base time.Time
+
+	switch {
+	case a.Trigger.Time != nil:
+		base = *a.Trigger.Time
+	case a.Trigger.Duration != nil && a.Trigger.Related == "END":
+		if e.End == nil {
+			return nil
+		}
+		base = a.Trigger.addTo(*e.End)
+	case a.Trigger.Duration != nil:
+		if e.Start == nil {
+			return nil
+		}
+		base = a.Trigger.addTo(*e.Start)
+	default:
+		return nil
+	}
+
+	triggers := []time.Time{base}
+	if a.Duration != nil {
+		for i := 1; i <= a.Repeat; i++ {
+			triggers = append(triggers, base.Add(time.Duration(i)*(*a.Duration)))
+		}
+	}
+
+	return triggers
+}
+
+// addTo applies a relative trigger to the given date, with its nominal
+// duration if it was parsed from a feed, or its exact one otherwise.
+func (t Trigger) addTo(d time.Time) time.Time {
+	if t.NominalDuration != nil {
+		return t.NominalDuration.AddTo(d)
+	}
+
+	return d.Add(*t.Duration)
+}
+
+// AlarmTriggers returns the trigger instants of all the alarms of the event.
+func (e Event) AlarmTriggers() []time.Time {
+	triggers := []time.Time{}
+	for _, a := range e.Alarms {
+		triggers = append(triggers, a.Triggers(e)...)
+	}
+
+	return triggers
+}
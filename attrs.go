@@ -2,6 +2,8 @@ package gocal
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/apognu/gocal/parser"
@@ -40,23 +42,122 @@ func resolve[T comparable](gc *Gocal, l *Line, dst *T, resolve func(gc *Gocal, l
 	return nil
 }
 
+// parseTime parses a date, resolving its TZID with the parser's timezone
+// resolver (see resolveTimezone). TZIDs that cannot be resolved at all are
+// considered UTC, which is reported once per TZID as a warning.
+func (gc *Gocal) parseTime(s string, params map[string]string, ty int, allday bool) (*time.Time, error) {
+	resolver := parser.TZResolverFunc(func(tzid string) (*time.Location, error) {
+		tz, err := gc.resolveTimezone(tzid)
+		if err == nil {
+			return tz, nil
+		}
+		if tz, err = parser.LoadTimezoneWithWindowsZones(tzid, gc.WindowsZones); err == nil {
+			return tz, nil
+		}
+
+		if !gc.fallbackTZIDs[tzid] {
+			if gc.fallbackTZIDs == nil {
+				gc.fallbackTZIDs = make(map[string]bool)
+			}
+			gc.fallbackTZIDs[tzid] = true
+			gc.warn(WarningTimezoneFallback, gc.line, "", fmt.Errorf("unknown timezone %s, considered UTC", tzid))
+		}
+
+		// Not failing keeps ParseTimeWithResolver from loading the timezone
+		// again, regardless of WindowsZones
+		return time.UTC, nil
+	})
+
+	return parser.ParseTimeWithResolver(s, params, ty, allday, gc.AllDayEventsTZ, resolver)
+}
+
+// resolveTimezone looks a TZID up in the timezones defined by the feed's
+// VTIMEZONE components, then falls back to the configured TZResolver, or to
+// the global mapper set with SetTZMapper if there is none.
+func (gc *Gocal) resolveTimezone(tzid string) (*time.Location, error) {
+	if tz, ok := gc.Timezones[tzid]; ok {
+		return tz.Location, nil
+	}
+
+	if gc.TZResolver != nil {
+		return gc.TZResolver.Resolve(tzid)
+	}
+
+	return parser.DefaultTZResolver.Resolve(tzid)
+}
+
+// parseDateList parses the dates of a multi-valued property such as EXDATE.
+// None of them is returned unless all of them could be parsed, so that the
+// property is dropped as a whole.
+func (gc *Gocal) parseDateList(l *Line) ([]time.Time, error) {
+	dates := make([]time.Time, 0)
+	for _, v := range l.values() {
+		d, err := gc.parseTime(v, l.Params, parser.TimeStart, false)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse: %w", err)
+		}
+		dates = append(dates, *d)
+	}
+
+	return dates, nil
+}
+
+// parsePeriod parses a PERIOD value, made of a start date-time and either an
+// end date-time or a duration, separated by a slash.
+func (gc *Gocal) parsePeriod(s string, params map[string]string) (*Period, error) {
+	tokens := strings.SplitN(s, "/", 2)
+	if len(tokens) != 2 {
+		return nil, fmt.Errorf("could not parse period: %s", s)
+	}
+
+	start, err := gc.parseTime(tokens[0], params, parser.TimeStart, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse: %w", err)
+	}
+
+	if strings.HasPrefix(tokens[1], "P") {
+		d, err := parser.ParseNominalDuration(tokens[1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse: %w", err)
+		}
+
+		return &Period{Start: *start, End: d.AddTo(*start)}, nil
+	}
+
+	end, err := gc.parseTime(tokens[1], params, parser.TimeStart, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse: %w", err)
+	}
+
+	return &Period{Start: *start, End: *end}, nil
+}
+
 func resolveString(gc *Gocal, l *Line) (string, string, error) {
 	return l.Value, "", nil
 }
 
+func resolveInt(gc *Gocal, l *Line) (int, int, error) {
+	i, err := strconv.Atoi(l.Value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not parse: %w", err)
+	}
+
+	return i, 0, nil
+}
+
 func resolveDate(gc *Gocal, l *Line) (*time.Time, *time.Time, error) {
-	d, err := parser.ParseTime(l.Value, l.Params, parser.TimeStart, false, gc.AllDayEventsTZ)
+	d, err := gc.parseTime(l.Value, l.Params, parser.TimeStart, false)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse: %s", err)
+		return nil, nil, fmt.Errorf("could not parse: %w", err)
 	}
 
 	return d, nil, nil
 }
 
 func resolveDateEnd(gc *Gocal, l *Line) (*time.Time, *time.Time, error) {
-	d, err := parser.ParseTime(l.Value, l.Params, parser.TimeEnd, false, gc.AllDayEventsTZ)
+	d, err := gc.parseTime(l.Value, l.Params, parser.TimeEnd, false)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse: %s", err)
+		return nil, nil, fmt.Errorf("could not parse: %w", err)
 	}
 
 	return d, nil, nil
@@ -65,7 +166,16 @@ func resolveDateEnd(gc *Gocal, l *Line) (*time.Time, *time.Time, error) {
 func resolveDuration(gc *Gocal, l *Line) (*time.Duration, *time.Duration, error) {
 	d, err := parser.ParseDuration(l.Value)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse: %s", err)
+		return nil, nil, fmt.Errorf("could not parse: %w", err)
+	}
+
+	return d, nil, nil
+}
+
+func resolveNominalDuration(gc *Gocal, l *Line) (*parser.NominalDuration, *parser.NominalDuration, error) {
+	d, err := parser.ParseNominalDuration(l.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse: %w", err)
 	}
 
 	return d, nil, nil
@@ -0,0 +1,655 @@
+package gocal
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apognu/gocal/parser"
+)
+
+const (
+	// DefaultProdID is the PRODID written by encoders unless specified otherwise.
+	DefaultProdID = "-//apognu//gocal//EN"
+
+	// Lines longer than that many octets are folded, as per RFC5545, 3.1.
+	foldLimit = 75
+)
+
+// Encoder writes calendars in the iCalendar format.
+type Encoder struct {
+	w      io.Writer
+	ProdID string
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, ProdID: DefaultProdID}
+}
+
+// component is a calendar component to be serialized, made of properties,
+// whose values are already encoded, and of nested components.
+type component struct {
+	Name       string
+	Properties []*Line
+	Components []*component
+}
+
+func (c *component) add(key string, params map[string]string, value string) {
+	c.Properties = append(c.Properties, &Line{Key: key, Params: params, Value: value})
+}
+
+func (c *component) addText(key, value string) {
+	if value != "" {
+		c.add(key, nil, escapeText(value))
+	}
+}
+
+// addURI adds a URI value, such as URL, which is not escaped like TEXT ones.
+func (c *component) addURI(key, value string) {
+	if value != "" {
+		c.add(key, nil, value)
+	}
+}
+
+func (c *component) addTime(key string, t *time.Time) {
+	if t != nil {
+		value, params := formatTime(*t, nil)
+		c.add(key, params, value)
+	}
+}
+
+// Encode writes a whole calendar, including the timezones defined in the feed
+// it was parsed from, its events, todos and journal entries.
+//
+// As the parser expands recurring events and journal entries into instances,
+// those are collapsed back into their series, written once from the original
+// DTSTART and DTEND of their first instance.
+func (enc *Encoder) Encode(gc *Gocal) error {
+	return enc.write(calendarComponent(gc, enc.ProdID))
+}
+
+// EncodeEvent writes a single VEVENT component.
+func (enc *Encoder) EncodeEvent(e Event) error {
+	return enc.write(eventComponent(e, false))
+}
+
+func (enc *Encoder) write(c *component) error {
+	if _, err := io.WriteString(enc.w, "BEGIN:"+c.Name+"\r\n"); err != nil {
+		return err
+	}
+
+	for _, p := range c.Properties {
+		if _, err := io.WriteString(enc.w, foldLine(encodeLine(p))); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range c.Components {
+		if err := enc.write(sub); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(enc.w, "END:"+c.Name+"\r\n")
+
+	return err
+}
+
+// emptyCalendar builds a VCALENDAR with its required properties only.
+func emptyCalendar(prodID string) *component {
+	c := &component{Name: "VCALENDAR"}
+	c.add("VERSION", nil, "2.0")
+	c.add("PRODID", nil, prodID)
+
+	return c
+}
+
+func calendarComponent(gc *Gocal, prodID string) *component {
+	c := emptyCalendar(prodID)
+	if gc.Method != "" {
+		c.add("METHOD", nil, gc.Method)
+	}
+
+	tzids := make([]string, 0, len(gc.Timezones))
+	for tzid := range gc.Timezones {
+		tzids = append(tzids, tzid)
+	}
+	sort.Strings(tzids)
+
+	for _, tzid := range tzids {
+		c.Components = append(c.Components, timezoneComponent(gc.Timezones[tzid]))
+	}
+
+	// Series are written as defined in the feed, with their master event and all
+	// their overrides, instead of their instances. Instances without a series are
+	// collapsed into a master event taking their raw start and end dates.
+	series := make(map[string]bool)
+	for _, e := range gc.Events {
+		switch {
+		case e.Series != nil:
+			if series[e.Uid] {
+				continue
+			}
+			series[e.Uid] = true
+
+			if e.Series.Master != nil {
+				c.Components = append(c.Components, eventComponent(*e.Series.Master, false))
+			}
+			for _, o := range e.Series.Overrides {
+				c.Components = append(c.Components, eventComponent(o, false))
+			}
+		case e.expanded:
+			if series[e.Uid] {
+				continue
+			}
+			series[e.Uid] = true
+
+			c.Components = append(c.Components, eventComponent(e, true))
+		default:
+			c.Components = append(c.Components, eventComponent(e, false))
+		}
+	}
+
+	for _, t := range gc.Todos {
+		c.Components = append(c.Components, todoComponent(t))
+	}
+
+	series = make(map[string]bool)
+	for _, j := range gc.Journals {
+		if j.expanded {
+			if series[j.Uid] {
+				continue
+			}
+			series[j.Uid] = true
+		}
+
+		c.Components = append(c.Components, journalComponent(j, j.expanded))
+	}
+
+	return c
+}
+
+func timezoneComponent(tz *Timezone) *component {
+	c := &component{Name: "VTIMEZONE"}
+	c.add("TZID", nil, tz.TZID)
+
+	for _, o := range tz.Observances {
+		sub := &component{Name: "STANDARD"}
+		if o.Daylight {
+			sub.Name = "DAYLIGHT"
+		}
+
+		sub.add("DTSTART", nil, o.Start)
+		sub.add("TZOFFSETFROM", nil, o.OffsetFrom)
+		sub.add("TZOFFSETTO", nil, o.OffsetTo)
+		if o.Name != "" {
+			sub.add("TZNAME", nil, escapeText(o.Name))
+		}
+		if o.RecurrenceRule != "" {
+			sub.add("RRULE", nil, o.RecurrenceRule)
+		}
+		for _, d := range o.RecurrenceDates {
+			sub.add("RDATE", nil, d)
+		}
+
+		c.Components = append(c.Components, sub)
+	}
+
+	return c
+}
+
+// eventComponent builds the VEVENT of an event. DTSTART and DTEND are written
+// as they were parsed, unless the event was moved, as is the case of the
+// instances of recurring events. The original dates can be forced with raw,
+// which also collapses instances back into their series.
+func eventComponent(e Event, raw bool) *component {
+	c := &component{Name: "VEVENT"}
+
+	instance := !raw && e.expanded
+
+	c.addText("UID", e.Uid)
+	c.addTime("DTSTAMP", e.Stamp)
+
+	if e.Start != nil {
+		raw = raw || isRawDate(*e.Start, e.RawStart)
+
+		switch {
+		case raw:
+			c.add("DTSTART", e.RawStart.Params, e.RawStart.Value)
+		default:
+			value, params := formatTime(*e.Start, e.RawStart.Params)
+			c.add("DTSTART", params, value)
+		}
+
+		switch {
+		case e.RawEnd.Value == "" && e.NominalDuration != nil:
+			c.add("DURATION", nil, e.NominalDuration.String())
+		case e.RawEnd.Value == "" && e.Duration != nil:
+			c.add("DURATION", nil, formatDuration(*e.Duration))
+		case raw && e.RawEnd.Value != "":
+			c.add("DTEND", e.RawEnd.Params, e.RawEnd.Value)
+		case !raw && e.End != nil:
+			params := e.RawEnd.Params
+			if params == nil {
+				params = e.RawStart.Params
+			}
+			value, params := formatEndTime(*e.End, params)
+			c.add("DTEND", params, value)
+		}
+	}
+
+	c.addTime("CREATED", e.Created)
+	c.addTime("LAST-MODIFIED", e.LastModified)
+	c.addText("SUMMARY", e.Summary)
+	c.addText("DESCRIPTION", e.Description)
+	c.addText("LOCATION", e.Location)
+	if e.Geo != nil {
+		c.add("GEO", nil, formatGeo(e.Geo))
+	}
+	c.addURI("URL", e.URL)
+	c.addText("STATUS", e.Status)
+	c.addText("CLASS", e.Class)
+	c.addText("COMMENT", e.Comment)
+	addList(c, "CATEGORIES", e.Categories)
+	addList(c, "RESOURCES", e.Resources)
+	addOrganizer(c, e.Organizer)
+	addAttendees(c, e.Attendees)
+	addAttachments(c, e.Attachments)
+	if e.Sequence != 0 {
+		c.add("SEQUENCE", nil, strconv.Itoa(e.Sequence))
+	}
+
+	// Overrides and expanded instances are written with their RECURRENCE-ID,
+	// unless the latter are collapsed back into their series. Instances are
+	// then occurrences of their series, without the recurrence properties that
+	// would otherwise start a new series on them.
+	if e.RawRecurrenceID.Value != "" && (instance || !e.expanded) {
+		params := dateParams(e.RawRecurrenceID.Params)
+		if e.RecurrenceRange != "" {
+			params["RANGE"] = e.RecurrenceRange
+		}
+		c.add("RECURRENCE-ID", params, e.RawRecurrenceID.Value)
+	}
+
+	if !instance {
+		if e.RecurrenceRuleString != "" {
+			c.add("RRULE", nil, e.RecurrenceRuleString)
+		}
+		for _, d := range e.RecurrenceDates {
+			value, params := formatTime(d, dateListParams(d, e.Start, e.RawStart.Params))
+			c.add("RDATE", params, value)
+		}
+		for _, p := range e.RecurrencePeriods {
+			start, params := formatTime(p.Start, nil)
+			end, _ := formatTime(p.End.In(p.Start.Location()), nil)
+			params["VALUE"] = "PERIOD"
+			c.add("RDATE", params, start+"/"+end)
+		}
+		for _, r := range e.ExcludeRuleStrings {
+			c.add("EXRULE", nil, r)
+		}
+		for _, d := range e.ExcludeDates {
+			value, params := formatTime(d, dateListParams(d, e.Start, e.RawStart.Params))
+			c.add("EXDATE", params, value)
+		}
+	}
+
+	addCustomAttributes(c, e.CustomAttributes)
+
+	for _, a := range e.Alarms {
+		c.Components = append(c.Components, alarmComponent(a))
+	}
+
+	return c
+}
+
+func alarmComponent(a Alarm) *component {
+	c := &component{Name: "VALARM"}
+
+	c.addText("ACTION", a.Action)
+
+	switch {
+	case a.Trigger.Time != nil:
+		c.add("TRIGGER", map[string]string{"VALUE": "DATE-TIME"}, a.Trigger.Time.UTC().Format("20060102T150405Z"))
+	case a.Trigger.Duration != nil:
+		var params map[string]string
+		if a.Trigger.Related == "END" {
+			params = map[string]string{"RELATED": "END"}
+		}
+		if a.Trigger.NominalDuration != nil {
+			c.add("TRIGGER", params, a.Trigger.NominalDuration.String())
+		} else {
+			c.add("TRIGGER", params, formatDuration(*a.Trigger.Duration))
+		}
+	}
+
+	if a.Repeat != 0 {
+		c.add("REPEAT", nil, strconv.Itoa(a.Repeat))
+	}
+	if a.Duration != nil {
+		c.add("DURATION", nil, formatDuration(*a.Duration))
+	}
+	c.addText("SUMMARY", a.Summary)
+	c.addText("DESCRIPTION", a.Description)
+	addAttendees(c, a.Attendees)
+	addAttachments(c, a.Attachments)
+	addCustomAttributes(c, a.CustomAttributes)
+
+	return c
+}
+
+func todoComponent(t Todo) *component {
+	c := &component{Name: "VTODO"}
+
+	c.addText("UID", t.Uid)
+	c.addTime("DTSTAMP", t.Stamp)
+	if t.Start != nil {
+		c.add("DTSTART", t.RawStart.Params, t.RawStart.Value)
+	}
+	switch {
+	case t.RawDue.Value == "" && t.NominalDuration != nil:
+		c.add("DURATION", nil, t.NominalDuration.String())
+	case t.RawDue.Value == "" && t.Duration != nil:
+		c.add("DURATION", nil, formatDuration(*t.Duration))
+	case t.Due != nil:
+		c.add("DUE", t.RawDue.Params, t.RawDue.Value)
+	}
+	c.addTime("COMPLETED", t.Completed)
+	c.addTime("CREATED", t.Created)
+	c.addTime("LAST-MODIFIED", t.LastModified)
+	c.addText("SUMMARY", t.Summary)
+	c.addText("DESCRIPTION", t.Description)
+	c.addText("LOCATION", t.Location)
+	if t.Geo != nil {
+		c.add("GEO", nil, formatGeo(t.Geo))
+	}
+	c.addURI("URL", t.URL)
+	c.addText("STATUS", t.Status)
+	if t.PercentComplete != 0 {
+		c.add("PERCENT-COMPLETE", nil, strconv.Itoa(t.PercentComplete))
+	}
+	if t.Priority != 0 {
+		c.add("PRIORITY", nil, strconv.Itoa(t.Priority))
+	}
+	c.addText("CLASS", t.Class)
+	c.addText("COMMENT", t.Comment)
+	addList(c, "CATEGORIES", t.Categories)
+	addList(c, "RESOURCES", t.Resources)
+	addOrganizer(c, t.Organizer)
+	addAttendees(c, t.Attendees)
+	addAttachments(c, t.Attachments)
+	if t.Sequence != 0 {
+		c.add("SEQUENCE", nil, strconv.Itoa(t.Sequence))
+	}
+	addCustomAttributes(c, t.CustomAttributes)
+
+	return c
+}
+
+func journalComponent(j Journal, raw bool) *component {
+	c := &component{Name: "VJOURNAL"}
+
+	c.addText("UID", j.Uid)
+	c.addTime("DTSTAMP", j.Stamp)
+	if j.Start != nil {
+		if raw || isRawDate(*j.Start, j.RawStart) {
+			c.add("DTSTART", j.RawStart.Params, j.RawStart.Value)
+		} else {
+			value, params := formatTime(*j.Start, j.RawStart.Params)
+			c.add("DTSTART", params, value)
+		}
+	}
+	c.addTime("CREATED", j.Created)
+	c.addTime("LAST-MODIFIED", j.LastModified)
+	c.addText("SUMMARY", j.Summary)
+	for _, d := range j.Descriptions {
+		c.addText("DESCRIPTION", d)
+	}
+	c.addURI("URL", j.URL)
+	c.addText("STATUS", j.Status)
+	c.addText("CLASS", j.Class)
+	c.addText("COMMENT", j.Comment)
+	addList(c, "CATEGORIES", j.Categories)
+	addOrganizer(c, j.Organizer)
+	addAttendees(c, j.Attendees)
+	addAttachments(c, j.Attachments)
+	if j.Sequence != 0 {
+		c.add("SEQUENCE", nil, strconv.Itoa(j.Sequence))
+	}
+	if j.RawRecurrenceID.Value != "" && !(raw && j.expanded) {
+		c.add("RECURRENCE-ID", dateParams(j.RawRecurrenceID.Params), j.RawRecurrenceID.Value)
+	}
+	if j.RecurrenceRuleString != "" {
+		c.add("RRULE", nil, j.RecurrenceRuleString)
+	}
+	for _, d := range j.ExcludeDates {
+		value, params := formatTime(d, dateListParams(d, j.Start, j.RawStart.Params))
+		c.add("EXDATE", params, value)
+	}
+	addCustomAttributes(c, j.CustomAttributes)
+
+	return c
+}
+
+// addList adds a multi-valued text property, such as CATEGORIES.
+func addList(c *component, name string, list []string) {
+	if len(list) == 0 {
+		return
+	}
+
+	values := make([]string, len(list))
+	for idx, v := range list {
+		values[idx] = escapeText(v)
+	}
+
+	c.add(name, nil, strings.Join(values, ","))
+}
+
+func addOrganizer(c *component, o *Organizer) {
+	if o == nil {
+		return
+	}
+
+	params := make(map[string]string)
+	if o.Cn != "" {
+		params["CN"] = o.Cn
+	}
+	if o.DirectoryDn != "" {
+		params["DIR"] = o.DirectoryDn
+	}
+
+	c.add("ORGANIZER", params, o.Value)
+}
+
+func addAttendees(c *component, attendees []Attendee) {
+	for _, a := range attendees {
+		params := make(map[string]string)
+		if a.Cn != "" {
+			params["CN"] = a.Cn
+		}
+		if a.DirectoryDn != "" {
+			params["DIR"] = a.DirectoryDn
+		}
+		if a.Status != "" {
+			params["PARTSTAT"] = a.Status
+		}
+		for key, val := range a.CustomAttributes {
+			params[key] = val
+		}
+
+		c.add("ATTENDEE", params, a.Value)
+	}
+}
+
+func addAttachments(c *component, attachments []Attachment) {
+	for _, a := range attachments {
+		params := make(map[string]string)
+		for key, val := range map[string]string{"VALUE": a.Type, "ENCODING": a.Encoding, "FMTTYPE": a.Mime, "FILENAME": a.Filename} {
+			if val != "" {
+				params[key] = val
+			}
+		}
+
+		c.add("ATTACH", params, a.Value)
+	}
+}
+
+func addCustomAttributes(c *component, attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		c.add(key, nil, escapeText(attrs[key]))
+	}
+}
+
+// isRawDate checks whether a date is still the one it was parsed from.
+func isRawDate(t time.Time, raw RawDate) bool {
+	if raw.Value == "" {
+		return false
+	}
+
+	value, _ := formatTime(t, raw.Params)
+
+	return value == raw.Value
+}
+
+// dateParams only keeps the parameters of a date that describe its format.
+func dateParams(params map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, key := range []string{"VALUE", "TZID"} {
+		if val, ok := params[key]; ok {
+			out[key] = val
+		}
+	}
+
+	return out
+}
+
+// dateListParams returns the parameters a date of RDATE or EXDATE is written
+// with: those of DTSTART, unless the date is in another zone, since dates of
+// these properties need not be in the zone of the event. Such dates are then
+// written in UTC.
+func dateListParams(d time.Time, start *time.Time, params map[string]string) map[string]string {
+	out := dateParams(params)
+	if out["VALUE"] != "DATE" && start != nil && d.Location().String() != start.Location().String() {
+		delete(out, "TZID")
+	}
+
+	return out
+}
+
+// formatTime formats a date the same way as the given parameters describe it,
+// which are returned along with the value. Without a VALUE or TZID parameter,
+// local dates are written as floating times, and others in UTC: their location
+// does not come from a VTIMEZONE of the feed, so its name would be left
+// undefined as a TZID.
+func formatTime(t time.Time, params map[string]string) (string, map[string]string) {
+	out := make(map[string]string, len(params))
+	for key, val := range params {
+		out[key] = val
+	}
+
+	switch {
+	case out["VALUE"] == "DATE":
+		return t.Format("20060102"), out
+	case out["TZID"] != "":
+		return t.Format("20060102T150405"), out
+	case t.Location() == time.Local:
+		return t.Format("20060102T150405"), out
+	default:
+		return t.UTC().Format("20060102T150405Z"), out
+	}
+}
+
+// formatEndTime formats an end date like formatTime, but turns the inclusive
+// end of all-day events back into the exclusive date they were parsed from.
+func formatEndTime(t time.Time, params map[string]string) (string, map[string]string) {
+	if params["VALUE"] == "DATE" {
+		t = t.Add(-time.Nanosecond).AddDate(0, 0, 1)
+	}
+
+	return formatTime(t, params)
+}
+
+func formatDuration(d time.Duration) string {
+	return parser.NominalDuration{Days: int(d / (24 * time.Hour)), Time: d % (24 * time.Hour)}.String()
+}
+
+func formatGeo(g *Geo) string {
+	return strconv.FormatFloat(g.Lat, 'f', -1, 64) + ";" + strconv.FormatFloat(g.Long, 'f', -1, 64)
+}
+
+// escapeText escapes a TEXT value, as per RFC5545, 3.3.11.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// encodeParam quotes parameter values that contain special characters, unless
+// they already are.
+func encodeParam(v string) string {
+	if strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) && len(v) > 1 {
+		return v
+	}
+	if strings.ContainsAny(v, ":;,") {
+		return `"` + v + `"`
+	}
+
+	return v
+}
+
+func encodeLine(l *Line) string {
+	var b strings.Builder
+
+	b.WriteString(l.Key)
+
+	keys := make([]string, 0, len(l.Params))
+	for key := range l.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		b.WriteString(";" + key + "=" + encodeParam(l.Params[key]))
+	}
+
+	b.WriteString(":" + l.Value)
+
+	return b.String()
+}
+
+// foldLine splits a content line into lines of at most 75 octets, without
+// breaking UTF-8 sequences, as per RFC5545, 3.1.
+func foldLine(line string) string {
+	var b strings.Builder
+
+	limit := foldLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		// Invalid UTF-8 may have no rune start to cut before
+		if cut == 0 {
+			cut = limit
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+
+		// Continuation lines start with a space, which counts in their length
+		limit = foldLimit - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package gocal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const encoderICS = `BEGIN:VCALENDAR
+METHOD:PUBLISH
+BEGIN:VTIMEZONE
+TZID:Custom Zone
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:19700329T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+TZNAME:CEST
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:allday@gocal
+DTSTAMP:20240101T000000Z
+DTSTART;VALUE=DATE:20240105
+DTEND;VALUE=DATE:20240107
+SUMMARY:Summary\, with\; special \\ characters
+DESCRIPTION:A very long description with multibyte characters (été, ünïcödé, 日本語) that needs to be folded over several lines
+ORGANIZER;CN=John Connor;DIR="ldap://example.net":mailto:john.connor@example.net
+ATTENDEE;PARTSTAT=ACCEPTED;CN=Antoine Popineau;X-RESPONSE-COMMENT="Not interested":mailto:antoine.popineau@example.net
+ATTACH;FMTTYPE=text/plain;FILENAME=notes.txt:https://example.net/notes.txt
+CATEGORIES:One,Two\, three
+RESOURCES:Projector
+GEO:48.85;2.35
+URL:https://example.net
+SEQUENCE:3
+X-CUSTOM:Custom value
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;RELATED=END:-PT1H30M
+REPEAT:2
+DURATION:PT5M
+DESCRIPTION:Reminder
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:recurring@gocal
+DTSTAMP:20240101T000000Z
+DTSTART;TZID=Custom Zone:20240108T090000
+DURATION:PT1H
+SUMMARY:Weekly
+RRULE:FREQ=WEEKLY;BYDAY=MO
+RDATE;TZID=Custom Zone:20240110T090000
+EXDATE;TZID=Custom Zone:20240115T090000
+EXDATE:20240129T080000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:recurring@gocal
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID;TZID=Custom Zone:20240122T090000
+DTSTART;TZID=Custom Zone:20240122T140000
+DTEND;TZID=Custom Zone:20240122T150000
+SUMMARY:Weekly (moved)
+END:VEVENT
+BEGIN:VTODO
+UID:todo@gocal
+DTSTAMP:20240101T000000Z
+DUE;TZID=Europe/Paris:20240110T120000
+SUMMARY:Todo
+PRIORITY:1
+PERCENT-COMPLETE:50
+RESOURCES:Pen,Paper
+END:VTODO
+BEGIN:VJOURNAL
+UID:journal@gocal
+DTSTAMP:20240101T000000Z
+DTSTART;VALUE=DATE:20240112
+DESCRIPTION:First
+DESCRIPTION:Second
+END:VJOURNAL
+END:VCALENDAR
+`
+
+func parseForEncoding(t *testing.T, ics string) *Gocal {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+
+	for idx := range gc.Events {
+		gc.Events[idx].delayed = nil
+	}
+	for idx := range gc.Todos {
+		gc.Todos[idx].delayed = nil
+	}
+
+	return gc
+}
+
+func Test_EncodeRoundTrip(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	assert.Len(t, gc.Events, 4)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewEncoder(&out).Encode(gc))
+
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+		assert.True(t, len(line) <= 75)
+		assert.True(t, utf8.ValidString(line))
+	}
+
+	rgc := parseForEncoding(t, out.String())
+
+	assert.Equal(t, gc.Method, rgc.Method)
+	assert.Equal(t, gc.Events, rgc.Events)
+	assert.Equal(t, gc.Todos, rgc.Todos)
+	assert.Equal(t, gc.Journals, rgc.Journals)
+	assert.Equal(t, gc.Timezones["Custom Zone"].Observances, rgc.Timezones["Custom Zone"].Observances)
+}
+
+func Test_EncodeEvent(t *testing.T) {
+	start := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	var out bytes.Buffer
+	err := NewEncoder(&out).EncodeEvent(Event{
+		Uid:     "event@gocal",
+		Summary: "Lunch; with friends, maybe",
+		Start:   &start,
+		End:     &end,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "BEGIN:VEVENT\r\n"+
+		"UID:event@gocal\r\n"+
+		"DTSTART:20240108T090000Z\r\n"+
+		"DTEND:20240108T100000Z\r\n"+
+		"SUMMARY:Lunch\\; with friends\\, maybe\r\n"+
+		"END:VEVENT\r\n", out.String())
+}
+
+func Test_EncodeEventValues(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	start := time.Date(2024, 1, 8, 9, 0, 0, 0, paris)
+	end := start.Add(time.Hour)
+
+	var out bytes.Buffer
+	err := NewEncoder(&out).EncodeEvent(Event{
+		Uid:   "event@gocal",
+		Start: &start,
+		End:   &end,
+		URL:   "http://example.com/?a=1;b=2,3",
+	})
+
+	assert.Nil(t, err)
+
+	// Locations not defined by a VTIMEZONE are written in UTC
+	assert.Contains(t, out.String(), "DTSTART:20240108T080000Z\r\n")
+	assert.Contains(t, out.String(), "DTEND:20240108T090000Z\r\n")
+	assert.NotContains(t, out.String(), "TZID")
+
+	// URIs are not escaped like text
+	assert.Contains(t, out.String(), "URL:http://example.com/?a=1;b=2,3\r\n")
+}
+
+func Test_EncodeRecurringInstance(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewEncoder(&out).EncodeEvent(gc.Events[3]))
+
+	// Instances are occurrences of their series, rather than new series
+	assert.Contains(t, out.String(), "DTSTART;TZID=Custom Zone:20240110T090000\r\n")
+	assert.Contains(t, out.String(), "DURATION:PT1H\r\n")
+	assert.Contains(t, out.String(), "RECURRENCE-ID;TZID=Custom Zone:20240110T090000\r\n")
+	assert.NotContains(t, out.String(), "RRULE")
+	assert.NotContains(t, out.String(), "RDATE")
+	assert.NotContains(t, out.String(), "EXDATE")
+
+	out.Reset()
+	assert.Nil(t, NewEncoder(&out).EncodeEvent(gc.Events[2]))
+
+	assert.Contains(t, out.String(), "DTSTART;TZID=Custom Zone:20240108T090000\r\n")
+	assert.Contains(t, out.String(), "RECURRENCE-ID;TZID=Custom Zone:20240108T090000\r\n")
+	assert.NotContains(t, out.String(), "RRULE")
+
+	// Series are written from their master
+	out.Reset()
+	assert.Nil(t, NewEncoder(&out).EncodeEvent(*gc.Events[2].Series.Master))
+
+	assert.Contains(t, out.String(), "RRULE:FREQ=WEEKLY;BYDAY=MO\r\n")
+	assert.NotContains(t, out.String(), "RECURRENCE-ID")
+}
+
+func Test_EncodeSeries(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(thisAndFutureICS))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 2)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewEncoder(&out).Encode(gc))
+
+	// Overrides outside of the parsing window are written along their series
+	assert.Equal(t, 3, strings.Count(out.String(), "BEGIN:VEVENT"))
+	assert.Contains(t, out.String(), "RECURRENCE-ID;RANGE=THISANDFUTURE:20240115T090000Z\r\n")
+	assert.Contains(t, out.String(), "RECURRENCE-ID:20240129T090000Z\r\n")
+
+	start, end = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	gc = NewParser(strings.NewReader(thisAndFutureICS))
+	gc.Start, gc.End = &start, &end
+	assert.Nil(t, gc.Parse())
+
+	rgc := NewParser(&out)
+	rgc.Start, rgc.End = &start, &end
+	assert.Nil(t, rgc.Parse())
+
+	assert.Len(t, rgc.Events, len(gc.Events))
+	for idx, e := range rgc.Events {
+		assert.Equal(t, *gc.Events[idx].Start, *e.Start)
+		assert.Equal(t, gc.Events[idx].Summary, e.Summary)
+	}
+}
+
+func Test_FoldLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 100)
+	folded := foldLine(line)
+
+	assert.Equal(t, line, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.True(t, len(l) <= 75)
+		assert.True(t, utf8.ValidString(l))
+	}
+
+	line = "DESCRIPTION:" + strings.Repeat("\x80", 100)
+	folded = foldLine(line)
+
+	assert.Equal(t, line, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.True(t, len(l) <= 75)
+	}
+}
+
+func Test_FormatDuration(t *testing.T) {
+	assert.Equal(t, "PT0S", formatDuration(0))
+	assert.Equal(t, "P1D", formatDuration(24*time.Hour))
+	assert.Equal(t, "-PT15M", formatDuration(-15*time.Minute))
+	assert.Equal(t, "P2DT1H10M30S", formatDuration(49*time.Hour+10*time.Minute+30*time.Second))
+}
@@ -2,6 +2,7 @@ package gocal
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"strconv"
@@ -13,8 +14,14 @@ import (
 
 func NewParser(r io.Reader) *Gocal {
 	return &Gocal{
-		scanner: bufio.NewScanner(r),
-		Events:  make([]Event, 0),
+		scanner:    bufio.NewScanner(r),
+		unfolder:   &unfolder{},
+		Events:     make([]Event, 0),
+		Todos:      make([]Todo, 0),
+		Journals:   make([]Journal, 0),
+		Timezones:  make(map[string]*Timezone),
+		Series:     make(map[string]*Series),
+		jOverrides: make(map[string][]Journal),
 		Strict: StrictParams{
 			Mode: StrictModeFailFeed,
 		},
@@ -23,6 +30,7 @@ func NewParser(r io.Reader) *Gocal {
 		},
 		SkipBounds:     false,
 		AllDayEventsTZ: time.UTC,
+		WindowsZones:   true,
 	}
 }
 
@@ -35,6 +43,99 @@ func (gc *Gocal) Parse() error {
 		return nil
 	}()
 
+	for {
+		e, err := gc.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		gc.Events = append(gc.Events, *e)
+	}
+}
+
+// ParseContext parses the feed like Parse, but stops as soon as the context is
+// done, in which case the context's error is returned. Cancellation is checked
+// between lines and while expanding recurring events.
+func (gc *Gocal) ParseContext(ctx context.Context) error {
+	gc.ctx = ctx
+	defer func() { gc.ctx = nil }()
+
+	return gc.Parse()
+}
+
+func (gc *Gocal) context() context.Context {
+	if gc.ctx == nil {
+		return context.Background()
+	}
+
+	return gc.ctx
+}
+
+// Next parses the feed up to its next event, which is returned instead of
+// being added to Events, and returns io.EOF once the whole feed was read.
+// Events are returned as soon as their END:VEVENT is reached, except for the
+// instances of recurring events: since RECURRENCE-ID overrides may appear
+// anywhere in the feed, those are held until its end, where they are returned
+// once overridden instances are dropped and THISANDFUTURE overrides applied.
+// Todos and journal entries are still collected in Todos and Journals.
+func (gc *Gocal) Next() (*Event, error) {
+	if gc.stack == nil {
+		if err := gc.begin(); err != nil {
+			return nil, err
+		}
+	}
+
+	for !gc.eof {
+		if err := gc.context().Err(); err != nil {
+			return nil, err
+		}
+
+		l, err, done := gc.parseLine()
+		gc.eof = done
+
+		// Scanners stop on read errors, such as lines over MaxLineSize, which
+		// must not be mistaken for the end of the feed.
+		if err := gc.scanner.Err(); err != nil {
+			return nil, &ParseError{Line: gc.unfolder.lines + 1, Err: fmt.Errorf("could not read line: %w", err)}
+		}
+
+		if err != nil {
+			if perr, ok := err.(*ParseError); ok {
+				gc.Warnings = append(gc.Warnings, Warning{Kind: WarningUnparseableLine, ParseError: perr})
+			}
+			continue
+		}
+
+		e, err := gc.parseComponentLine(l)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			return e, nil
+		}
+	}
+
+	if gc.instances != nil {
+		if err := gc.resolveInstances(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(gc.pending) == 0 {
+		return nil, io.EOF
+	}
+
+	e := gc.pending[0]
+	gc.pending = gc.pending[1:]
+
+	return &e, nil
+}
+
+// begin sets the parser up before reading the first line.
+func (gc *Gocal) begin() error {
 	if gc.Start == nil {
 		start := time.Now().Add(-1 * 24 * time.Hour)
 		gc.Start = &start
@@ -44,162 +145,435 @@ func (gc *Gocal) Parse() error {
 		gc.End = &end
 	}
 
-	gc.scanner.Scan()
+	gc.stack = &Context{Value: ContextRoot}
+	gc.instances = make([]Event, 0)
+	gc.jInstances = make([]Journal, 0)
 
-	rInstances := make([]Event, 0)
-	ctx := &Context{Value: ContextRoot}
-	for {
-		l, err, done := gc.parseLine()
+	if gc.decode != nil {
+		lines, err := gc.decode()
 		if err != nil {
-			if done {
-				break
+			return &ParseError{Err: err}
+		}
+		gc.lines = lines
+	} else {
+		maxLineSize := gc.MaxLineSize
+		if maxLineSize <= 0 {
+			maxLineSize = DefaultMaxLineSize
+		}
+
+		gc.scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		gc.scanner.Split(gc.unfolder.split)
+		gc.scanner.Scan()
+	}
+
+	return nil
+}
+
+// resolveInstances queues the instances of recurring events and adds the ones
+// of recurring journal entries, once all overrides are known.
+func (gc *Gocal) resolveInstances() error {
+	expanded := make(map[*Series]bool)
+
+	for _, i := range gc.instances {
+		// Instances moved by RANGE=THISANDFUTURE overrides may come from beyond
+		// the parsing window, so their series is expanded again now that all its
+		// overrides are known.
+		if s := i.Series; s.Master != nil && i.expanded && s.isShifted() {
+			if !expanded[s] {
+				instances, err := s.instances(gc.context(), *gc.Start, *gc.End)
+				if err != nil {
+					return err
+				}
+
+				gc.pending = append(gc.pending, instances...)
+				expanded[s] = true
 			}
 			continue
 		}
 
-		if l.IsValue("VCALENDAR") {
+		if i.Series.isOverridden(*i.RecurrenceID) {
 			continue
 		}
 
-		if ctx.Value == ContextRoot && l.Is("BEGIN", "VEVENT") {
-			ctx = ctx.Nest(ContextEvent)
+		i = i.Series.applyRangeOverride(i)
+		if gc.IsInRange(i) {
+			gc.pending = append(gc.pending, i)
+		}
+	}
 
-			gc.buffer = &Event{Valid: true, delayed: make([]*Line, 0)}
-		} else if ctx.Value == ContextRoot && l.IsKey("METHOD") {
-			gc.Method = l.Value
-		} else if ctx.Value == ContextEvent && l.Is("END", "VEVENT") {
-			if ctx.Previous == nil {
-				return fmt.Errorf("got an END:* without matching BEGIN:*")
-			}
-			ctx = ctx.Previous
+	for _, j := range gc.jInstances {
+		if !gc.IsRecurringJournalOverriden(&j) && gc.IsJournalInRange(j) {
+			gc.Journals = append(gc.Journals, j)
+		}
+	}
 
-			for _, d := range gc.buffer.delayed {
-				gc.parseEvent(d)
-			}
+	gc.instances, gc.jInstances = nil, nil
+
+	return nil
+}
+
+// parseComponentLine handles a single line depending on the component it is
+// part of, and returns the event it completes, if any.
+func (gc *Gocal) parseComponentLine(l *Line) (*Event, error) {
+	gc.line = l
+
+	if l.IsValue("VCALENDAR") {
+		return nil, nil
+	}
+
+	if gc.stack.Value == ContextRoot && l.Is("BEGIN", "VEVENT") {
+		gc.stack = gc.stack.Nest(ContextEvent)
+
+		gc.buffer = &Event{Valid: true, delayed: make([]*Line, 0)}
+	} else if gc.stack.Value == ContextRoot && l.IsKey("METHOD") {
+		gc.Method = l.Value
+	} else if gc.stack.Value == ContextEvent && l.Is("END", "VEVENT") {
+		if gc.stack.Previous == nil {
+			return nil, newParseError(l, "", fmt.Errorf("got an END:* without matching BEGIN:*"))
+		}
+		gc.stack = gc.stack.Previous
+
+		// Events skipped for one of their alarms were already reported
+		if gc.buffer.skipped {
+			return nil, nil
+		}
 
-			// Some tools return single full day events as inclusive (same DTSTART
-			// and DTEND) which goes against RFC. Standard tools still handle those
-			// as events spanning 24 hours.
-			if gc.buffer.RawStart.Value == gc.buffer.RawEnd.Value {
-				if value, ok := gc.buffer.RawEnd.Params["VALUE"]; ok && value == "DATE" {
-					gc.buffer.End, err = parser.ParseTime(gc.buffer.RawEnd.Value, gc.buffer.RawEnd.Params, parser.TimeEnd, true, gc.AllDayEventsTZ)
+		for _, d := range gc.buffer.delayed {
+			gc.line = d
+			if err := gc.parseEvent(d); err != nil {
+				if err := gc.handleAttributeError(d, gc.buffer.Uid, err, &gc.buffer.Valid); err != nil {
+					return nil, err
 				}
 			}
+		}
+		gc.line = l
+
+		// Some tools return single full day events as inclusive (same DTSTART
+		// and DTEND) which goes against RFC. Standard tools still handle those
+		// as events spanning 24 hours.
+		if gc.buffer.RawStart.Value == gc.buffer.RawEnd.Value {
+			if value, ok := gc.buffer.RawEnd.Params["VALUE"]; ok && value == "DATE" {
+				gc.buffer.End, _ = gc.parseTime(gc.buffer.RawEnd.Value, gc.buffer.RawEnd.Params, parser.TimeEnd, true)
+			}
+		}
 
-			// If an event has a VALUE=DATE start date and no end date, event lasts a day
-			if gc.buffer.End == nil && gc.buffer.RawStart.Params["VALUE"] == "DATE" {
-				d := (*gc.buffer.Start).Add(24 * time.Hour)
+		// If an event has a VALUE=DATE start date and no end date, event lasts a
+		// day, which is not always 24 hours long
+		if gc.buffer.End == nil && gc.buffer.RawStart.Params["VALUE"] == "DATE" {
+			d := gc.buffer.Start.AddDate(0, 0, 1)
 
-				gc.buffer.End = &d
+			gc.buffer.End = &d
+		}
+
+		if err := gc.checkEvent(); err != nil {
+			switch gc.Strict.Mode {
+			case StrictModeFailFeed:
+				return nil, newParseError(l, gc.buffer.Uid, err)
+			case StrictModeFailEvent:
+				gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, err)
+				return nil, nil
+			case StrictModeFailAttribute:
+				// Events without start or end are skipped below
+				if gc.buffer.Start != nil && gc.buffer.End != nil {
+					gc.warn(WarningDroppedAttribute, l, gc.buffer.Uid, err)
+				}
 			}
+		}
+
+		if gc.buffer.Start == nil || gc.buffer.End == nil {
+			gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, fmt.Errorf("could not parse event without start or end"))
+			return nil, nil
+		}
+		if gc.Strict.Mode == StrictModeFailEvent && !gc.buffer.Valid {
+			gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, errInvalidComponent)
+			return nil, nil
+		}
+
+		// Overrides replace instances of their series whether or not they are
+		// themselves within bounds, since they may have been moved out of them.
+		if gc.buffer.RecurrenceID != nil {
+			s := gc.seriesOf(gc.buffer.Uid)
+			gc.buffer.Series = s
+
+			o := *gc.buffer
+			o.delayed = nil
+			s.Overrides = append(s.Overrides, o)
+		} else if gc.buffer.IsRecurring {
+			s := gc.seriesOf(gc.buffer.Uid)
+			gc.buffer.Series = s
+
+			master := *gc.buffer
+			master.delayed = nil
+			s.Master = &master
+			s.Exceptions = master.ExcludeDates
+		}
 
-			if err := gc.checkEvent(); err != nil {
-				switch gc.Strict.Mode {
-				case StrictModeFailFeed:
-					return fmt.Errorf("gocal error: %s", err)
-				case StrictModeFailEvent:
-					continue
+		if gc.buffer.IsRecurring {
+			additionalInstances, err := gc.ExpandRecurringEvent(gc.buffer)
+			if err != nil {
+				if err := gc.context().Err(); err != nil {
+					return nil, err
 				}
+
+				err = fmt.Errorf("could not expand recurring event: %w", err)
+				if gc.Strict.Mode == StrictModeFailFeed {
+					return nil, newParseError(l, gc.buffer.Uid, err)
+				}
+
+				gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, err)
+				return nil, nil
 			}
 
-			if gc.buffer.Start == nil || gc.buffer.End == nil {
-				continue
+			gc.instances = append(gc.instances, additionalInstances...)
+		} else {
+			if !gc.SkipBounds && !gc.IsInRange(*gc.buffer) {
+				return nil, nil
 			}
 
-			if gc.buffer.IsRecurring {
-				additionalInstances, err := gc.ExpandRecurringEvent(gc.buffer)
-				if err != nil {
-					switch gc.Strict.Mode {
-					case StrictModeFailFeed:
-						return fmt.Errorf("error expanding event with UID '%s': %s", gc.buffer.Uid, err)
-					case StrictModeFailEvent:
-						continue
-					}
+			e := *gc.buffer
+			return &e, nil
+		}
+	} else if gc.stack.Value == ContextRoot && l.Is("BEGIN", "VTODO") {
+		gc.stack = gc.stack.Nest(ContextTodo)
+
+		gc.todoBuffer = &Todo{Valid: true, delayed: make([]*Line, 0)}
+	} else if gc.stack.Value == ContextTodo && l.Is("END", "VTODO") {
+		gc.stack = gc.stack.Previous
+
+		for _, d := range gc.todoBuffer.delayed {
+			gc.line = d
+			if err := gc.parseTodo(d); err != nil {
+				if err := gc.handleAttributeError(d, gc.todoBuffer.Uid, err, &gc.todoBuffer.Valid); err != nil {
+					return nil, err
 				}
+			}
+		}
+		gc.line = l
+
+		if err := gc.checkTodo(); err != nil {
+			switch gc.Strict.Mode {
+			case StrictModeFailFeed:
+				return nil, newParseError(l, gc.todoBuffer.Uid, err)
+			case StrictModeFailEvent:
+				gc.warn(WarningSkippedComponent, l, gc.todoBuffer.Uid, err)
+				return nil, nil
+			case StrictModeFailAttribute:
+				gc.warn(WarningDroppedAttribute, l, gc.todoBuffer.Uid, err)
+			}
+		}
 
-				rInstances = append(rInstances, additionalInstances...)
-			} else {
-				if gc.buffer.End == nil || gc.buffer.Start == nil {
-					continue
-				}
-				if !gc.SkipBounds && !gc.IsInRange(*gc.buffer) {
-					continue
+		if gc.Strict.Mode == StrictModeFailEvent && !gc.todoBuffer.Valid {
+			gc.warn(WarningSkippedComponent, l, gc.todoBuffer.Uid, errInvalidComponent)
+			return nil, nil
+		}
+		if !gc.SkipBounds && !gc.IsTodoInRange(*gc.todoBuffer) {
+			return nil, nil
+		}
+
+		gc.Todos = append(gc.Todos, *gc.todoBuffer)
+	} else if gc.stack.Value == ContextRoot && l.Is("BEGIN", "VJOURNAL") {
+		gc.stack = gc.stack.Nest(ContextJournal)
+
+		gc.journalBuffer = &Journal{Valid: true}
+	} else if gc.stack.Value == ContextJournal && l.Is("END", "VJOURNAL") {
+		gc.stack = gc.stack.Previous
+
+		if err := gc.checkJournal(); err != nil {
+			switch gc.Strict.Mode {
+			case StrictModeFailFeed:
+				return nil, newParseError(l, gc.journalBuffer.Uid, err)
+			case StrictModeFailEvent:
+				gc.warn(WarningSkippedComponent, l, gc.journalBuffer.Uid, err)
+				return nil, nil
+			case StrictModeFailAttribute:
+				gc.warn(WarningDroppedAttribute, l, gc.journalBuffer.Uid, err)
+			}
+		}
+
+		if gc.Strict.Mode == StrictModeFailEvent && !gc.journalBuffer.Valid {
+			gc.warn(WarningSkippedComponent, l, gc.journalBuffer.Uid, errInvalidComponent)
+			return nil, nil
+		}
+
+		if gc.journalBuffer.RecurrenceID != nil {
+			gc.jOverrides[gc.journalBuffer.Uid] = append(gc.jOverrides[gc.journalBuffer.Uid], *gc.journalBuffer)
+		}
+
+		if gc.journalBuffer.IsRecurring && gc.journalBuffer.Start != nil {
+			additionalInstances, err := gc.ExpandRecurringJournal(gc.journalBuffer)
+			if err != nil {
+				if err := gc.context().Err(); err != nil {
+					return nil, err
 				}
-				if gc.Strict.Mode == StrictModeFailEvent && !gc.buffer.Valid {
-					continue
+
+				err = fmt.Errorf("could not expand recurring journal: %w", err)
+				if gc.Strict.Mode == StrictModeFailFeed {
+					return nil, newParseError(l, gc.journalBuffer.Uid, err)
 				}
 
-				gc.Events = append(gc.Events, *gc.buffer)
+				gc.warn(WarningSkippedComponent, l, gc.journalBuffer.Uid, err)
+				return nil, nil
 			}
-		} else if l.IsKey("BEGIN") {
-			ctx = ctx.Nest(ContextUnknown)
-		} else if l.IsKey("END") {
-			if ctx.Previous == nil {
-				return fmt.Errorf("got an END:%s without matching BEGIN:%s", l.Value, l.Value)
+
+			gc.jInstances = append(gc.jInstances, additionalInstances...)
+		} else {
+			if !gc.SkipBounds && !gc.IsJournalInRange(*gc.journalBuffer) {
+				return nil, nil
 			}
-			ctx = ctx.Previous
-		} else if ctx.Value == ContextEvent {
-			if err := gc.parseEvent(l); err != nil {
-				if _, ok := err.(DuplicateAttributeError); ok {
-					switch gc.Duplicate.Mode {
-					case DuplicateModeFailStrict:
-						switch gc.Strict.Mode {
-						case StrictModeFailFeed:
-							return fmt.Errorf("gocal error: %s", err)
-						case StrictModeFailEvent:
-							gc.buffer.Valid = false
-							continue
-						case StrictModeFailAttribute:
-							gc.buffer.Valid = false
-							continue
-						}
-					}
-				}
 
-				return fmt.Errorf("gocal error: %s", err)
+			gc.Journals = append(gc.Journals, *gc.journalBuffer)
+		}
+	} else if gc.stack.Value == ContextEvent && l.Is("BEGIN", "VALARM") {
+		gc.stack = gc.stack.Nest(ContextAlarm)
+
+		gc.alarmBuffer = &Alarm{Valid: true}
+	} else if gc.stack.Value == ContextAlarm && l.Is("END", "VALARM") {
+		gc.stack = gc.stack.Previous
+
+		if gc.buffer.skipped {
+			return nil, nil
+		}
+
+		if err := gc.checkAlarm(); err != nil {
+			switch gc.Strict.Mode {
+			case StrictModeFailFeed:
+				return nil, newParseError(l, gc.buffer.Uid, err)
+			case StrictModeFailEvent:
+				gc.buffer.Valid, gc.buffer.skipped = false, true
+				gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, err)
+				return nil, nil
+			case StrictModeFailAttribute:
+				gc.warn(WarningDroppedAttribute, l, gc.buffer.Uid, err)
 			}
-		} else {
-			continue
 		}
 
-		if done {
-			break
+		if gc.Strict.Mode == StrictModeFailEvent && !gc.alarmBuffer.Valid {
+			gc.buffer.Valid, gc.buffer.skipped = false, true
+			gc.warn(WarningSkippedComponent, l, gc.buffer.Uid, errInvalidComponent)
+			return nil, nil
 		}
-	}
 
-	for _, i := range rInstances {
-		if !gc.IsRecurringInstanceOverriden(&i) && gc.IsInRange(i) {
-			gc.Events = append(gc.Events, i)
+		gc.buffer.Alarms = append(gc.buffer.Alarms, *gc.alarmBuffer)
+	} else if gc.stack.Value == ContextRoot && l.Is("BEGIN", "VTIMEZONE") {
+		gc.stack = gc.stack.Nest(ContextTimezone)
+
+		gc.tzBuffer = &Timezone{}
+	} else if gc.stack.Value == ContextTimezone && l.Is("END", "VTIMEZONE") {
+		gc.stack = gc.stack.Previous
+
+		if err := gc.buildTimezone(); err != nil {
+			if gc.Strict.Mode == StrictModeFailFeed {
+				return nil, newParseError(l, "", err)
+			}
+
+			gc.warn(WarningSkippedComponent, l, "", err)
+			return nil, nil
+		}
+
+		// Dates are resolved as they are parsed, so components preceding the
+		// definition of their timezone cannot use it
+		if gc.fallbackTZIDs[gc.tzBuffer.TZID] {
+			gc.warn(WarningTimezoneFallback, l, "", fmt.Errorf("timezone %s is defined after dates using it, which were considered UTC", gc.tzBuffer.TZID))
+		}
+
+		gc.Timezones[gc.tzBuffer.TZID] = gc.tzBuffer
+	} else if gc.stack.Value == ContextTimezone && (l.Is("BEGIN", "STANDARD") || l.Is("BEGIN", "DAYLIGHT")) {
+		gc.stack = gc.stack.Nest(ContextObservance)
+
+		gc.obsBuffer = &parser.TimezoneObservance{Daylight: l.Value == "DAYLIGHT"}
+	} else if gc.stack.Value == ContextObservance && (l.Is("END", "STANDARD") || l.Is("END", "DAYLIGHT")) {
+		gc.stack = gc.stack.Previous
+
+		gc.tzBuffer.Observances = append(gc.tzBuffer.Observances, *gc.obsBuffer)
+	} else if l.IsKey("BEGIN") {
+		gc.stack = gc.stack.Nest(ContextUnknown)
+	} else if l.IsKey("END") {
+		if gc.stack.Previous == nil {
+			return nil, newParseError(l, "", fmt.Errorf("got an END:%s without matching BEGIN:%s", l.Value, l.Value))
+		}
+		gc.stack = gc.stack.Previous
+	} else if gc.stack.Value == ContextEvent {
+		if err := gc.parseEvent(l); err != nil {
+			if err := gc.handleAttributeError(l, gc.buffer.Uid, err, &gc.buffer.Valid); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	} else if gc.stack.Value == ContextTimezone {
+		if l.IsKey("TZID") {
+			gc.tzBuffer.TZID = l.Value
+		}
+	} else if gc.stack.Value == ContextObservance {
+		gc.parseObservance(l)
+	} else if gc.stack.Value == ContextAlarm {
+		if err := gc.parseAlarm(l); err != nil {
+			if err := gc.handleAttributeError(l, gc.buffer.Uid, err, &gc.alarmBuffer.Valid); err != nil {
+				return nil, err
+			}
+			return nil, nil
 		}
+	} else if gc.stack.Value == ContextJournal {
+		if err := gc.parseJournal(l); err != nil {
+			if err := gc.handleAttributeError(l, gc.journalBuffer.Uid, err, &gc.journalBuffer.Valid); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	} else if gc.stack.Value == ContextTodo {
+		if err := gc.parseTodo(l); err != nil {
+			if err := gc.handleAttributeError(l, gc.todoBuffer.Uid, err, &gc.todoBuffer.Valid); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	} else {
+		return nil, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 func (gc *Gocal) parseLine() (*Line, error, bool) {
-	// Get initial current line and check if that was the last one
+	if gc.decode != nil {
+		return gc.nextDecodedLine()
+	}
+
+	// Get current line, unfolded while scanned, and check if that was the last one
+	number := gc.unfolder.start
 	l := gc.scanner.Text()
 	done := !gc.scanner.Scan()
 
-	// If not, try and figure out if value is continued on next line
-	if !done {
-		for strings.HasPrefix(gc.scanner.Text(), " ") {
-			l = l + strings.TrimPrefix(gc.scanner.Text(), " ")
-
-			if done = !gc.scanner.Scan(); done {
-				break
-			}
-		}
+	// Blank lines are not content lines, and are skipped silently
+	if strings.TrimSpace(l) == "" {
+		return nil, fmt.Errorf("empty line"), done
 	}
 
 	tokens := splitLineTokens(l)
 	if len(tokens) < 2 {
-		return nil, fmt.Errorf("could not parse item: %s", l), done
+		return nil, &ParseError{Line: number, Text: l, Err: fmt.Errorf("could not parse item: %s", l)}, done
 	}
 
 	attr, params := parser.ParseParameters(tokens[0])
 
-	return &Line{Key: attr, Params: params, Value: parser.UnescapeString(strings.TrimPrefix(tokens[1], " "))}, nil, done
+	raw := strings.TrimPrefix(tokens[1], " ")
+
+	return &Line{Key: attr, Params: params, Value: parser.UnescapeString(raw), number: number, text: l, raw: raw}, nil, done
+}
+
+// nextDecodedLine returns the next line of a document decoded from another
+// syntax than iCalendar. Values are unescaped like the ones of iCalendar lines.
+func (gc *Gocal) nextDecodedLine() (*Line, error, bool) {
+	if len(gc.lines) == 0 {
+		return nil, fmt.Errorf("no more lines"), true
+	}
+
+	l := *gc.lines[0]
+	l.text = encodeLine(&l)
+	l.raw = l.Value
+	l.Value = parser.UnescapeString(l.Value)
+	gc.lines = gc.lines[1:]
+
+	return &l, nil, len(gc.lines) == 0
 }
 
 // splitLineTokens assures that property parameters that are quoted due to containing special
@@ -265,10 +639,10 @@ func (gc *Gocal) parseEvent(l *Line) error {
 			return nil
 		}
 
-		if err := resolve(gc, l, &gc.buffer.Duration, resolveDuration, func(gc *Gocal, out *time.Duration) {
+		if err := resolve(gc, l, &gc.buffer.NominalDuration, resolveNominalDuration, func(gc *Gocal, out *parser.NominalDuration) {
 			if out != nil {
-				gc.buffer.Duration = out
-				end := gc.buffer.Start.Add(*out)
+				d, end := out.Duration(), out.AddTo(*gc.buffer.Start)
+				gc.buffer.Duration = &d
 				gc.buffer.End = &end
 			}
 		}); err != nil {
@@ -287,32 +661,75 @@ func (gc *Gocal) parseEvent(l *Line) error {
 			return err
 		}
 	case "RRULE":
-		if len(gc.buffer.RecurrenceRule) != 0 {
+		if gc.buffer.RecurrenceRule != nil {
 			return NewDuplicateAttribute(l.Key, l.Value)
 		}
 
 		if gc.buffer.RecurrenceRule == nil || gc.Duplicate.Mode == DuplicateModeKeepLast {
-			var err error
-
-			gc.buffer.IsRecurring = true
-			if gc.buffer.RecurrenceRule, err = parser.ParseRecurrenceRule(l.Value); err != nil {
+			rule, err := parser.ParseRecurrenceRule(l.Value)
+			if err != nil {
 				return err
 			}
+
+			gc.buffer.IsRecurring = true
+			gc.buffer.RecurrenceRule = rule
 			gc.buffer.RecurrenceRuleString = l.Value
 		}
 	case "RECURRENCE-ID":
-		if err := resolve(gc, l, &gc.buffer.RecurrenceID, resolveString, nil); err != nil {
+		if err := resolve(gc, l, &gc.buffer.RecurrenceID, resolveDate, func(gc *Gocal, out *time.Time) {
+			gc.buffer.RawRecurrenceID = RawDate{Value: l.Value, Params: l.Params}
+			gc.buffer.RecurrenceRange = l.Params["RANGE"]
+		}); err != nil {
 			return err
 		}
+	case "RDATE":
+		/*
+			Reference: https://icalendar.org/iCalendar-RFC-5545/3-8-5-2-recurrence-date-times.html
+			Values can be lists of dates, date-times or periods
+		*/
+		// Values are only kept once they are all parsed, since the attribute is
+		// dropped as a whole otherwise
+		dates, periods := make([]time.Time, 0), make([]Period, 0)
+		for _, v := range l.values() {
+			if l.Params["VALUE"] == "PERIOD" || strings.Contains(v, "/") {
+				p, err := gc.parsePeriod(v, l.Params)
+				if err != nil {
+					return err
+				}
+				periods = append(periods, *p)
+				continue
+			}
+
+			d, err := gc.parseTime(v, l.Params, parser.TimeStart, false)
+			if err != nil {
+				return fmt.Errorf("could not parse: %w", err)
+			}
+			dates = append(dates, *d)
+		}
+
+		gc.buffer.RecurrenceDates = append(gc.buffer.RecurrenceDates, dates...)
+		gc.buffer.RecurrencePeriods = append(gc.buffer.RecurrencePeriods, periods...)
+		gc.buffer.IsRecurring = true
+	case "EXRULE":
+		// EXRULE was deprecated by RFC 5545, but is still emitted by some legacy servers
+		rule, err := parser.ParseRecurrenceRule(l.Value)
+		if err != nil {
+			return err
+		}
+
+		gc.buffer.ExcludeRules = append(gc.buffer.ExcludeRules, rule)
+		gc.buffer.ExcludeRuleStrings = append(gc.buffer.ExcludeRuleStrings, l.Value)
 	case "EXDATE":
 		/*
 			Reference: https://icalendar.org/iCalendar-RFC-5545/3-8-5-1-exception-date-times.html
 			Several parameters are allowed.  We should pass parameters we have
+			Values can be lists of dates or date-times
 		*/
-		d, err := parser.ParseTime(l.Value, l.Params, parser.TimeStart, false, gc.AllDayEventsTZ)
-		if err == nil {
-			gc.buffer.ExcludeDates = append(gc.buffer.ExcludeDates, *d)
+		dates, err := gc.parseDateList(l)
+		if err != nil {
+			return err
 		}
+		gc.buffer.ExcludeDates = append(gc.buffer.ExcludeDates, dates...)
 	case "SEQUENCE":
 		gc.buffer.Sequence, _ = strconv.Atoi(l.Value)
 	case "LOCATION":
@@ -328,42 +745,17 @@ func (gc *Gocal) parseEvent(l *Line) error {
 			return err
 		}
 	case "ATTENDEE":
-		attendee := Attendee{
-			Value: l.Value,
-		}
-		for key, val := range l.Params {
-			key := strings.ToUpper(key)
-			switch key {
-			case "CN":
-				attendee.Cn = val
-			case "DIR":
-				attendee.DirectoryDn = val
-			case "PARTSTAT":
-				attendee.Status = val
-			default:
-				if strings.HasPrefix(key, "X-") {
-					if attendee.CustomAttributes == nil {
-						attendee.CustomAttributes = make(map[string]string)
-					}
-					attendee.CustomAttributes[key] = val
-				}
-			}
-		}
-		gc.buffer.Attendees = append(gc.buffer.Attendees, attendee)
+		gc.buffer.Attendees = append(gc.buffer.Attendees, parseAttendee(l))
 	case "ATTACH":
-		gc.buffer.Attachments = append(gc.buffer.Attachments, Attachment{
-			Type:     l.Params["VALUE"],
-			Encoding: l.Params["ENCODING"],
-			Mime:     l.Params["FMTTYPE"],
-			Filename: l.Params["FILENAME"],
-			Value:    l.Value,
-		})
+		gc.buffer.Attachments = append(gc.buffer.Attachments, parseAttachment(l))
 	case "GEO":
 		if err := resolve(gc, l, &gc.buffer.Geo, resolveGeo, nil); err != nil {
 			return err
 		}
 	case "CATEGORIES":
-		gc.buffer.Categories = strings.Split(l.Value, ",")
+		gc.buffer.Categories = append(gc.buffer.Categories, l.values()...)
+	case "RESOURCES":
+		gc.buffer.Resources = append(gc.buffer.Resources, l.values()...)
 	case "URL":
 		gc.buffer.URL = l.Value
 	case "COMMENT":
@@ -371,16 +763,71 @@ func (gc *Gocal) parseEvent(l *Line) error {
 	case "CLASS":
 		gc.buffer.Class = l.Value
 	default:
-		key := strings.ToUpper(l.Key)
-		if strings.HasPrefix(key, "X-") {
-			if gc.buffer.CustomAttributes == nil {
-				gc.buffer.CustomAttributes = make(map[string]string)
+		parseCustomAttribute(l, &gc.buffer.CustomAttributes)
+	}
+
+	return nil
+}
+
+// handleAttributeError applies the configured strict mode to an error returned
+// while parsing a component attribute, flagging the component as invalid and
+// recording a warning when it is kept. A non-nil return value means the feed
+// must be aborted.
+func (gc *Gocal) handleAttributeError(l *Line, uid string, err error, valid *bool) error {
+	if gc.Strict.Mode == StrictModeFailFeed {
+		return newParseError(l, uid, err)
+	}
+
+	*valid = false
+	gc.warn(WarningDroppedAttribute, l, uid, err)
+
+	return nil
+}
+
+func parseAttendee(l *Line) Attendee {
+	attendee := Attendee{
+		Value: l.Value,
+	}
+	for key, val := range l.Params {
+		key := strings.ToUpper(key)
+		switch key {
+		case "CN":
+			attendee.Cn = val
+		case "DIR":
+			attendee.DirectoryDn = val
+		case "PARTSTAT":
+			attendee.Status = val
+		default:
+			if strings.HasPrefix(key, "X-") {
+				if attendee.CustomAttributes == nil {
+					attendee.CustomAttributes = make(map[string]string)
+				}
+				attendee.CustomAttributes[key] = val
 			}
-			gc.buffer.CustomAttributes[key] = l.Value
 		}
 	}
 
-	return nil
+	return attendee
+}
+
+func parseAttachment(l *Line) Attachment {
+	return Attachment{
+		Type:     l.Params["VALUE"],
+		Encoding: l.Params["ENCODING"],
+		Mime:     l.Params["FMTTYPE"],
+		Filename: l.Params["FILENAME"],
+		Value:    l.Value,
+	}
+}
+
+func parseCustomAttribute(l *Line, attrs *map[string]string) {
+	key := strings.ToUpper(l.Key)
+	if strings.HasPrefix(key, "X-") {
+		if *attrs == nil {
+			*attrs = make(map[string]string)
+		}
+		(*attrs)[key] = l.Value
+	}
 }
 
 func (gc *Gocal) checkEvent() error {
@@ -403,6 +850,8 @@ func (gc *Gocal) checkEvent() error {
 	return nil
 }
 
+// SetTZMapper sets the global timezone mapper, used by parsers that do not
+// have their own TZResolver.
 func SetTZMapper(cb func(s string) (*time.Location, error)) {
 	parser.TZMapper = cb
 }
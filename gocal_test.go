@@ -1,11 +1,18 @@
 package gocal
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/apognu/gocal/parser"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -713,6 +720,12 @@ func Test_InvalidEventFailAttribute(t *testing.T) {
 	assert.Equal(t, 2, len(gc.Events))
 	assert.False(t, gc.Events[0].Valid)
 	assert.True(t, gc.Events[1].Valid)
+
+	// The invalid event is kept, which is reported
+	assert.Len(t, gc.Warnings, 1)
+	assert.Equal(t, WarningDroppedAttribute, gc.Warnings[0].Kind)
+	assert.Equal(t, "one@gocal", gc.Warnings[0].UID)
+	assert.Contains(t, gc.Warnings[0].Error(), "DTSTAMP")
 }
 
 const durationICS = `BEGIN:VCALENDAR
@@ -888,3 +901,1270 @@ func Test_RecirrenceICSWithTZID(t *testing.T) {
 	assert.Equal(t, 1, len(gc.Events))
 	assert.Equal(t, "regular event", gc.Events[0].Summary)
 }
+
+const todoICS = `BEGIN:VCALENDAR
+BEGIN:VTODO
+UID:todo-1@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240110T090000Z
+DUE:20240115T170000Z
+SUMMARY:Write the report
+STATUS:IN-PROCESS
+PERCENT-COMPLETE:40
+PRIORITY:1
+CATEGORIES:WORK,REPORTS
+END:VTODO
+BEGIN:VTODO
+UID:todo-2@gocal
+DTSTAMP:20240101T090000Z
+DURATION:PT2H
+DTSTART:20240112T090000Z
+SUMMARY:Call the plumber
+STATUS:COMPLETED
+COMPLETED:20240112T103000Z
+PERCENT-COMPLETE:100
+END:VTODO
+BEGIN:VTODO
+UID:todo-3@gocal
+DTSTAMP:20240101T090000Z
+DUE:20230101T090000Z
+SUMMARY:Out of range
+END:VTODO
+BEGIN:VTODO
+UID:todo-4@gocal
+DTSTAMP:20240101T090000Z
+SUMMARY:Someday
+END:VTODO
+END:VCALENDAR`
+
+func Test_ParseTodo(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(todoICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 0)
+	assert.Len(t, gc.Todos, 3)
+
+	assert.Equal(t, "todo-1@gocal", gc.Todos[0].Uid)
+	assert.Equal(t, "Write the report", gc.Todos[0].Summary)
+	assert.Equal(t, time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC), *gc.Todos[0].Due)
+	assert.Equal(t, "IN-PROCESS", gc.Todos[0].Status)
+	assert.Equal(t, 40, gc.Todos[0].PercentComplete)
+	assert.Equal(t, 1, gc.Todos[0].Priority)
+	assert.Equal(t, []string{"WORK", "REPORTS"}, gc.Todos[0].Categories)
+
+	assert.Equal(t, "todo-2@gocal", gc.Todos[1].Uid)
+	assert.Equal(t, time.Date(2024, 1, 12, 11, 0, 0, 0, time.UTC), *gc.Todos[1].Due)
+	assert.Equal(t, time.Date(2024, 1, 12, 10, 30, 0, 0, time.UTC), *gc.Todos[1].Completed)
+	assert.Equal(t, 100, gc.Todos[1].PercentComplete)
+
+	assert.Equal(t, "todo-4@gocal", gc.Todos[2].Uid)
+	assert.Nil(t, gc.Todos[2].Due)
+}
+
+func Test_TodoDurationAcrossDST(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VTODO
+UID:todo@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Europe/Paris:20240330T090000
+DURATION:P1D
+SUMMARY:Due tomorrow
+END:VTODO
+END:VCALENDAR`
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Todos, 1)
+
+	tz, _ := time.LoadLocation("Europe/Paris")
+
+	// Daylight saving time starts on March, 31st, so that day lasts 23 hours
+	assert.True(t, gc.Todos[0].Due.Equal(time.Date(2024, 3, 31, 9, 0, 0, 0, tz)))
+	assert.Equal(t, parser.NominalDuration{Days: 1}, *gc.Todos[0].NominalDuration)
+	assert.Equal(t, 24*time.Hour, *gc.Todos[0].Duration)
+}
+
+const invalidTodoICS = `BEGIN:VCALENDAR
+BEGIN:VTODO
+UID:one@gocal
+SUMMARY:Invalid todo without DTSTAMP
+END:VTODO
+BEGIN:VTODO
+UID:two@gocal
+DTSTAMP:20240101T090000Z
+PRIORITY:1
+PRIORITY:2
+SUMMARY:Todo with duplicate priority
+END:VTODO
+BEGIN:VTODO
+UID:three@gocal
+DTSTAMP:20240101T090000Z
+SUMMARY:Valid todo
+END:VTODO
+END:VCALENDAR`
+
+func Test_InvalidTodo(t *testing.T) {
+	gc := NewParser(strings.NewReader(invalidTodoICS))
+	err := gc.Parse()
+
+	assert.NotNil(t, err)
+
+	gc = NewParser(strings.NewReader(invalidTodoICS))
+	gc.Strict.Mode = StrictModeFailEvent
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Todos, 1)
+	assert.Equal(t, "three@gocal", gc.Todos[0].Uid)
+
+	gc = NewParser(strings.NewReader(invalidTodoICS))
+	gc.Strict.Mode = StrictModeFailAttribute
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Todos, 3)
+	assert.False(t, gc.Todos[0].Valid)
+	assert.False(t, gc.Todos[1].Valid)
+	assert.True(t, gc.Todos[2].Valid)
+	assert.Len(t, gc.Warnings, 2)
+	assert.Equal(t, "one@gocal", gc.Warnings[0].UID)
+	assert.Contains(t, gc.Warnings[0].Error(), "DTSTAMP")
+
+	gc = NewParser(strings.NewReader(invalidTodoICS))
+	gc.Strict.Mode = StrictModeFailAttribute
+	gc.Duplicate.Mode = DuplicateModeKeepLast
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, gc.Todos[1].Priority)
+	assert.True(t, gc.Todos[1].Valid)
+}
+
+const journalICS = `BEGIN:VCALENDAR
+BEGIN:VJOURNAL
+UID:journal-1@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;VALUE=DATE:20240105
+SUMMARY:Team notebook
+DESCRIPTION:First entry
+DESCRIPTION:Second entry
+ATTACH;FMTTYPE=text/plain:https://example.net/notes.txt
+END:VJOURNAL
+BEGIN:VJOURNAL
+UID:journal-2@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+SUMMARY:Weekly standup notes
+RRULE:FREQ=WEEKLY;COUNT=4
+EXDATE:20240115T090000Z
+END:VJOURNAL
+BEGIN:VJOURNAL
+UID:journal-2@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240108T100000Z
+RECURRENCE-ID:20240108T090000Z
+SUMMARY:Moved standup notes
+END:VJOURNAL
+BEGIN:VJOURNAL
+UID:journal-3@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20230105T090000Z
+SUMMARY:Out of range
+END:VJOURNAL
+END:VCALENDAR`
+
+func Test_ParseJournal(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(journalICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 0)
+	assert.Len(t, gc.Journals, 4)
+
+	assert.Equal(t, "Team notebook", gc.Journals[0].Summary)
+	assert.Equal(t, []string{"First entry", "Second entry"}, gc.Journals[0].Descriptions)
+	assert.Len(t, gc.Journals[0].Attachments, 1)
+	assert.Equal(t, "text/plain", gc.Journals[0].Attachments[0].Mime)
+
+	assert.Equal(t, "Moved standup notes", gc.Journals[1].Summary)
+	assert.Equal(t, time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC), *gc.Journals[1].Start)
+
+	assert.Equal(t, "Weekly standup notes", gc.Journals[2].Summary)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), *gc.Journals[2].Start)
+	assert.Equal(t, time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC), *gc.Journals[3].Start)
+}
+
+func Test_DuplicateJournalAttributes(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VJOURNAL
+UID:journal-1@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240105T090000Z
+SUMMARY:First summary
+SUMMARY:Second summary
+END:VJOURNAL
+END:VCALENDAR`
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	err := gc.Parse()
+
+	assert.NotNil(t, err)
+
+	gc = NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	gc.Duplicate.Mode = DuplicateModeKeepLast
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Journals, 1)
+	assert.Equal(t, "Second summary", gc.Journals[0].Summary)
+}
+
+const alarmICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:alarm@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Weekly meeting
+RRULE:FREQ=WEEKLY;COUNT=3
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Meeting soon
+TRIGGER:-PT15M
+REPEAT:2
+DURATION:PT5M
+END:VALARM
+BEGIN:VALARM
+ACTION:EMAIL
+SUMMARY:Meeting over
+DESCRIPTION:Write the minutes
+TRIGGER;RELATED=END:PT0S
+ATTENDEE:mailto:john.connor@example.net
+END:VALARM
+BEGIN:VALARM
+ACTION:AUDIO
+TRIGGER;VALUE=DATE-TIME:20231231T120000Z
+END:VALARM
+END:VEVENT
+END:VCALENDAR`
+
+func Test_Alarms(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(alarmICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 3)
+
+	alarms := gc.Events[0].Alarms
+
+	assert.Len(t, alarms, 3)
+	assert.Equal(t, "DISPLAY", alarms[0].Action)
+	assert.Equal(t, -15*time.Minute, *alarms[0].Trigger.Duration)
+	assert.Equal(t, "START", alarms[0].Trigger.Related)
+	assert.Equal(t, 2, alarms[0].Repeat)
+	assert.Equal(t, "EMAIL", alarms[1].Action)
+	assert.Equal(t, "END", alarms[1].Trigger.Related)
+	assert.Equal(t, "mailto:john.connor@example.net", alarms[1].Attendees[0].Value)
+	assert.Equal(t, time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC), *alarms[2].Trigger.Time)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 8, 8, 45, 0, 0, time.UTC),
+		time.Date(2024, 1, 8, 8, 50, 0, 0, time.UTC),
+		time.Date(2024, 1, 8, 8, 55, 0, 0, time.UTC),
+	}, alarms[0].Triggers(gc.Events[1]))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 15, 8, 45, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 8, 50, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 8, 55, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC),
+	}, gc.Events[2].AlarmTriggers())
+}
+
+func Test_InvalidAlarm(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:alarm@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+BEGIN:VALARM
+ACTION:DISPLAY
+END:VALARM
+END:VEVENT
+END:VCALENDAR`
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	err := gc.Parse()
+
+	assert.NotNil(t, err)
+
+	gc = NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	gc.Strict.Mode = StrictModeFailEvent
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Empty(t, gc.Events)
+	assert.Len(t, gc.Warnings, 1)
+	assert.Equal(t, WarningSkippedComponent, gc.Warnings[0].Kind)
+	assert.Equal(t, 9, gc.Warnings[0].Line)
+	assert.Contains(t, gc.Warnings[0].Error(), "without TRIGGER")
+
+	gc = NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	gc.Strict.Mode = StrictModeFailAttribute
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 1)
+	assert.Len(t, gc.Events[0].Alarms, 1)
+	assert.False(t, gc.Events[0].Alarms[0].Valid)
+	assert.Len(t, gc.Warnings, 1)
+	assert.Equal(t, WarningDroppedAttribute, gc.Warnings[0].Kind)
+	assert.Contains(t, gc.Warnings[0].Error(), "without TRIGGER")
+}
+
+func Test_AlarmAcrossDST(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:alarm@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Europe/Paris:20240331T090000
+DTEND;TZID=Europe/Paris:20240331T100000
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-P1D
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT24H
+END:VALARM
+END:VEVENT
+END:VCALENDAR`
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.SkipBounds = true
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 1)
+
+	tz, _ := time.LoadLocation("Europe/Paris")
+
+	// Daylight saving time starts on March, 31st, the day before lasts 23 hours
+	triggers := gc.Events[0].AlarmTriggers()
+	assert.Len(t, triggers, 2)
+	assert.True(t, triggers[0].Equal(time.Date(2024, 3, 30, 9, 0, 0, 0, tz)))
+	assert.True(t, triggers[1].Equal(time.Date(2024, 3, 30, 8, 0, 0, 0, tz)))
+	assert.Equal(t, -24*time.Hour, *gc.Events[0].Alarms[0].Trigger.Duration)
+}
+
+const vtimezoneICS = `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:W. Europe Standard Time
+BEGIN:STANDARD
+DTSTART:16010101T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=10
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:outlook@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=W. Europe Standard Time:20240320T090000
+DTEND;TZID=W. Europe Standard Time:20240320T100000
+SUMMARY:Weekly sync
+RRULE:FREQ=WEEKLY;COUNT=3
+END:VEVENT
+END:VCALENDAR`
+
+func Test_VTimezone(t *testing.T) {
+	start, end := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(vtimezoneICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Timezones, 1)
+	assert.Len(t, gc.Timezones["W. Europe Standard Time"].Observances, 2)
+	assert.Len(t, gc.Events, 3)
+
+	// Daylight saving time starts on March, 31st in this timezone
+	assert.Equal(t, time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), gc.Events[0].Start.UTC())
+	assert.Equal(t, time.Date(2024, 3, 27, 8, 0, 0, 0, time.UTC), gc.Events[1].Start.UTC())
+	assert.Equal(t, time.Date(2024, 4, 3, 7, 0, 0, 0, time.UTC), gc.Events[2].Start.UTC())
+	assert.Equal(t, "W. Europe Standard Time", gc.Events[2].Start.Location().String())
+	assert.Equal(t, 9, gc.Events[2].Start.Hour())
+}
+
+const tzResolverICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:tenant@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Head Office:20240110T090000
+DTEND;TZID=Head Office:20240110T100000
+END:VEVENT
+END:VCALENDAR`
+
+func Test_TZResolver(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tenants := map[string]string{
+		"tenant1": "Asia/Tokyo",
+		"tenant2": "America/Los_Angeles",
+		"tenant3": "Europe/Paris",
+	}
+
+	var wg sync.WaitGroup
+	for _, tzid := range tenants {
+		wg.Add(1)
+
+		go func(tzid string) {
+			defer wg.Done()
+
+			tz, _ := time.LoadLocation(tzid)
+
+			gc := NewParser(strings.NewReader(tzResolverICS))
+			gc.Start, gc.End = &start, &end
+			gc.TZResolver = parser.TZResolverFunc(func(s string) (*time.Location, error) {
+				return tz, nil
+			})
+			err := gc.Parse()
+
+			assert.Nil(t, err)
+			assert.Len(t, gc.Events, 1)
+			assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, tz), *gc.Events[0].Start)
+		}(tzid)
+	}
+
+	wg.Wait()
+}
+
+func Test_WindowsZones(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	ics := strings.ReplaceAll(tzResolverICS, "Head Office", "Pacific Standard Time")
+	tz, _ := time.LoadLocation("America/Los_Angeles")
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, tz), *gc.Events[0].Start)
+	assert.Len(t, gc.Warnings, 0)
+
+	gc = NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+	gc.WindowsZones = false
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), *gc.Events[0].Start)
+	assert.Len(t, gc.Warnings, 1)
+	assert.Equal(t, WarningTimezoneFallback, gc.Warnings[0].Kind)
+
+	// Quoted TZIDs are resolved by their name
+	gc = NewParser(strings.NewReader(strings.ReplaceAll(tzResolverICS, "Head Office", `"Pacific Standard Time"`)))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, tz), *gc.Events[0].Start)
+	assert.Len(t, gc.Warnings, 0)
+}
+
+const rdateICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:rdate-1@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Weekly with extra dates
+RRULE:FREQ=WEEKLY;COUNT=2
+RDATE:20240103T090000Z
+RDATE;VALUE=PERIOD:20240105T140000Z/20240105T170000Z,20240106T080000Z/PT30M
+END:VEVENT
+BEGIN:VEVENT
+UID:rdate-2@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;VALUE=DATE:20240110
+DTEND;VALUE=DATE:20240111
+SUMMARY:Only explicit dates
+RDATE;VALUE=DATE:20240115,20240120
+EXDATE;VALUE=DATE:20240115
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceDates(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(rdateICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 7)
+
+	assert.Len(t, gc.Events[0].RecurrenceDates, 1)
+	assert.Len(t, gc.Events[0].RecurrencePeriods, 2)
+
+	expected := []struct{ start, end time.Time }{
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 5, 14, 0, 0, 0, time.UTC), time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 6, 8, 0, 0, 0, time.UTC), time.Date(2024, 1, 6, 8, 30, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for idx, exp := range expected {
+		assert.Equal(t, exp.start, *gc.Events[idx].Start)
+		assert.Equal(t, exp.end, *gc.Events[idx].End)
+	}
+
+	assert.True(t, gc.Events[5].IsRecurring)
+	assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), *gc.Events[5].Start)
+	assert.Equal(t, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), *gc.Events[6].Start)
+}
+
+const multiValueICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:multi@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=WEEKLY;COUNT=5
+EXDATE:20240108T090000Z,20240115T090000Z
+EXDATE:20240122T090000Z
+RDATE:20240103T090000Z,20240104T090000Z
+RDATE:20240105T090000Z
+CATEGORIES:Work,Meetings\, weekly
+CATEGORIES:Team
+RESOURCES:Projector,Whiteboard
+RESOURCES:Coffee
+END:VEVENT
+BEGIN:VTODO
+UID:multi-todo@gocal
+DTSTAMP:20240101T090000Z
+DUE:20240110T090000Z
+CATEGORIES:Home
+CATEGORIES:Chores,Weekly
+RESOURCES:Vacuum
+RESOURCES:Mop\,bucket
+END:VTODO
+BEGIN:VJOURNAL
+UID:multi-journal@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;VALUE=DATE:20240101
+RRULE:FREQ=DAILY;COUNT=4
+EXDATE;VALUE=DATE:20240102,20240103
+CATEGORIES:Notes
+CATEGORIES:Daily
+END:VJOURNAL
+END:VCALENDAR`
+
+func Test_MultiValueProperties(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(multiValueICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 5)
+
+	e := gc.Events[0]
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC),
+	}, e.ExcludeDates)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC),
+	}, e.RecurrenceDates)
+	assert.Equal(t, []string{"Work", "Meetings, weekly", "Team"}, e.Categories)
+	assert.Equal(t, []string{"Projector", "Whiteboard", "Coffee"}, e.Resources)
+
+	expected := []time.Time{
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC),
+	}
+	for idx, e := range gc.Events {
+		assert.Equal(t, expected[idx], *e.Start)
+	}
+
+	assert.Equal(t, []string{"Home", "Chores", "Weekly"}, gc.Todos[0].Categories)
+	assert.Equal(t, []string{"Vacuum", "Mop,bucket"}, gc.Todos[0].Resources)
+
+	assert.Len(t, gc.Journals, 2)
+	assert.Equal(t, []string{"Notes", "Daily"}, gc.Journals[0].Categories)
+	assert.Len(t, gc.Journals[0].ExcludeDates, 2)
+	assert.Equal(t, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), *gc.Journals[1].Start)
+}
+
+const exruleICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:exrule@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Every day but on mondays
+RRULE:FREQ=DAILY;COUNT=14
+EXRULE:FREQ=WEEKLY;BYDAY=MO
+END:VEVENT
+END:VCALENDAR`
+
+func Test_ExcludeRule(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(exruleICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 12)
+	assert.Equal(t, []string{"FREQ=WEEKLY;BYDAY=MO"}, gc.Events[0].ExcludeRuleStrings)
+	assert.Equal(t, []parser.WeekdayNum{{Day: time.Monday}}, gc.Events[0].ExcludeRules[0].ByDay)
+
+	for _, e := range gc.Events {
+		assert.NotEqual(t, time.Monday, e.Start.Weekday())
+	}
+}
+
+const invalidRRuleICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:invalid-rrule@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=SOMETIMES;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:valid-rrule@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=2
+END:VEVENT
+END:VCALENDAR`
+
+func Test_InvalidRecurrenceRule(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(invalidRRuleICS))
+	gc.Start, gc.End = &start, &end
+
+	var perr *ParseError
+	assert.True(t, errors.As(gc.Parse(), &perr))
+	assert.Equal(t, "RRULE", perr.Key)
+
+	gc = NewParser(strings.NewReader(invalidRRuleICS))
+	gc.Start, gc.End = &start, &end
+	gc.Strict.Mode = StrictModeFailEvent
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 2)
+	assert.Equal(t, "valid-rrule@gocal", gc.Events[0].Uid)
+	assert.Equal(t, []parser.WeekdayNum{{N: -1, Day: time.Friday}}, gc.Events[0].RecurrenceRule.ByDay)
+	assert.Equal(t, time.Date(2024, 1, 26, 9, 0, 0, 0, time.UTC), *gc.Events[0].Start)
+	assert.Equal(t, WarningSkippedComponent, gc.Warnings[len(gc.Warnings)-1].Kind)
+
+	gc = NewParser(strings.NewReader(invalidRRuleICS))
+	gc.Start, gc.End = &start, &end
+	gc.Strict.Mode = StrictModeFailAttribute
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 3)
+	assert.Equal(t, "invalid-rrule@gocal", gc.Events[0].Uid)
+	assert.False(t, gc.Events[0].Valid)
+	assert.False(t, gc.Events[0].IsRecurring)
+	assert.Nil(t, gc.Events[0].RecurrenceRule)
+}
+
+const thisAndFutureICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:series@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Weekly sync
+RRULE:FREQ=WEEKLY;COUNT=6
+END:VEVENT
+BEGIN:VEVENT
+UID:series@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID;RANGE=THISANDFUTURE:20240115T090000Z
+DTSTART:20240115T140000Z
+DTEND:20240115T153000Z
+SUMMARY:Weekly sync (afternoon)
+LOCATION:Room 2
+END:VEVENT
+BEGIN:VEVENT
+UID:series@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID:20240129T090000Z
+DTSTART:20240129T160000Z
+DTEND:20240129T170000Z
+SUMMARY:Weekly sync (moved once)
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceRangeThisAndFuture(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(thisAndFutureICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 6)
+
+	assert.Equal(t, "THISANDFUTURE", gc.Events[0].RecurrenceRange)
+	assert.Equal(t, "Weekly sync (afternoon)", gc.Events[0].Summary)
+	assert.Equal(t, "Weekly sync (moved once)", gc.Events[1].Summary)
+
+	assert.Equal(t, "Weekly sync", gc.Events[2].Summary)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), *gc.Events[2].Start)
+	assert.Equal(t, "Weekly sync", gc.Events[3].Summary)
+	assert.Equal(t, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC), *gc.Events[3].Start)
+
+	for _, e := range gc.Events[4:] {
+		assert.Equal(t, "Weekly sync (afternoon)", e.Summary)
+		assert.Equal(t, "Room 2", e.Location)
+		assert.Equal(t, 14, e.Start.Hour())
+		assert.Equal(t, 90*time.Minute, e.End.Sub(*e.Start))
+		assert.Empty(t, e.RecurrenceRange)
+		assert.True(t, e.IsRecurring)
+	}
+	assert.Equal(t, time.Date(2024, 1, 22, 14, 0, 0, 0, time.UTC), *gc.Events[4].Start)
+	assert.Equal(t, time.Date(2024, 2, 5, 14, 0, 0, 0, time.UTC), *gc.Events[5].Start)
+}
+
+const shiftedRangeICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:shifted@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Weekly sync
+RRULE:FREQ=WEEKLY;COUNT=10
+END:VEVENT
+BEGIN:VEVENT
+UID:shifted@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID;RANGE=THISANDFUTURE:20240129T090000Z
+DTSTART:20240119T090000Z
+DTEND:20240119T100000Z
+SUMMARY:Weekly sync (ten days earlier)
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceRangeShiftedIntoWindow(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(shiftedRangeICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+
+	starts := make([]time.Time, 0)
+	for _, e := range gc.Events {
+		starts = append(starts, *e.Start)
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 19, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC),
+		// Originally on February 5th
+		time.Date(2024, 1, 26, 9, 0, 0, 0, time.UTC),
+	}, starts)
+}
+
+const movedOverridesICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:moved@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Daily standup
+RRULE:FREQ=DAILY;COUNT=10
+END:VEVENT
+BEGIN:VEVENT
+UID:moved@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID:20240105T090000Z
+DTSTART:20240120T090000Z
+DTEND:20240120T100000Z
+SUMMARY:Standup moved out of the window
+END:VEVENT
+BEGIN:VEVENT
+UID:moved@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID:20240110T090000Z
+DTSTART:20240106T120000Z
+DTEND:20240106T130000Z
+SUMMARY:Standup moved into the window
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceOverridesMovedAcrossBounds(t *testing.T) {
+	start, end := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(movedOverridesICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 3)
+
+	assert.Equal(t, "Standup moved into the window", gc.Events[0].Summary)
+	assert.Equal(t, time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC), *gc.Events[1].Start)
+	assert.Equal(t, time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC), *gc.Events[2].Start)
+
+	for _, e := range gc.Events {
+		assert.NotEqual(t, time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC), *e.Start)
+	}
+
+	start, end = time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+
+	gc = NewParser(strings.NewReader(movedOverridesICS))
+	gc.Start, gc.End = &start, &end
+	err = gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 1)
+	assert.Equal(t, time.Date(2024, 1, 9, 9, 0, 0, 0, time.UTC), *gc.Events[0].Start)
+}
+
+const recurrenceIDICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:rid@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Europe/Paris:20240101T090000
+DTEND;TZID=Europe/Paris:20240101T100000
+SUMMARY:Daily standup
+RRULE:FREQ=DAILY;COUNT=5
+END:VEVENT
+BEGIN:VEVENT
+UID:rid@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID;TZID=Europe/Paris:20240103T090000
+DTSTART:20240103T100000Z
+DTEND:20240103T110000Z
+SUMMARY:Late standup
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceID(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(recurrenceIDICS))
+	gc.Start, gc.End = &start, &end
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 5)
+
+	tz, _ := time.LoadLocation("Europe/Paris")
+
+	o := gc.Events[0]
+	assert.Equal(t, "Late standup", o.Summary)
+	assert.True(t, o.RecurrenceID.Equal(time.Date(2024, 1, 3, 9, 0, 0, 0, tz)))
+	assert.Equal(t, RawDate{Value: "20240103T090000", Params: map[string]string{"TZID": "Europe/Paris"}}, o.RawRecurrenceID)
+
+	for idx, e := range gc.Events[1:] {
+		assert.Equal(t, *e.Start, *e.RecurrenceID)
+		assert.Equal(t, RawDate{Value: e.Start.Format("20060102T150405"), Params: map[string]string{"TZID": "Europe/Paris"}}, e.RawRecurrenceID)
+		assert.False(t, gc.IsRecurringInstanceOverriden(&gc.Events[idx+1]))
+	}
+	for _, e := range gc.Events {
+		assert.NotEqual(t, time.Date(2024, 1, 3, 9, 0, 0, 0, tz), *e.Start)
+	}
+}
+
+const dstICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:allday@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;VALUE=DATE:20240330
+DTEND;VALUE=DATE:20240331
+SUMMARY:All-day
+RRULE:FREQ=DAILY;COUNT=2
+END:VEVENT
+BEGIN:VEVENT
+UID:nominal@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Europe/Paris:20240330T090000
+DURATION:P1D
+SUMMARY:Nominal day
+RRULE:FREQ=DAILY;COUNT=1
+END:VEVENT
+BEGIN:VEVENT
+UID:exact@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Europe/Paris:20240330T090000
+DURATION:PT24H
+SUMMARY:Exact day
+RRULE:FREQ=DAILY;COUNT=1
+END:VEVENT
+END:VCALENDAR`
+
+func Test_RecurrenceAcrossDST(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Paris")
+	start, end := time.Date(2024, 3, 29, 0, 0, 0, 0, tz), time.Date(2024, 4, 2, 0, 0, 0, 0, tz)
+
+	gc := NewParser(strings.NewReader(dstICS))
+	gc.Start, gc.End = &start, &end
+	gc.AllDayEventsTZ = tz
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 4)
+
+	ends := make(map[string][]time.Time)
+	rids := make(map[string][]RawDate)
+	for _, e := range gc.Events {
+		ends[e.Summary] = append(ends[e.Summary], *e.End)
+		rids[e.Summary] = append(rids[e.Summary], e.RawRecurrenceID)
+	}
+
+	assert.Equal(t, time.Date(2024, 3, 30, 23, 59, 59, 0, tz), ends["All-day"][0].Truncate(time.Second))
+	assert.Equal(t, time.Date(2024, 3, 31, 23, 59, 59, 0, tz), ends["All-day"][1].Truncate(time.Second))
+	assert.Equal(t, time.Date(2024, 3, 31, 9, 0, 0, 0, tz), ends["Nominal day"][0])
+	assert.Equal(t, time.Date(2024, 3, 31, 10, 0, 0, 0, tz), ends["Exact day"][0])
+	assert.Equal(t, 24*time.Hour, *gc.Events[3].Duration)
+
+	// Instances of all-day events are identified by their date
+	assert.Equal(t, RawDate{Value: "20240331", Params: map[string]string{"VALUE": "DATE"}}, rids["All-day"][1])
+}
+
+func Test_Next(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(thisAndFutureICS))
+	gc.Start, gc.End = &start, &end
+
+	summaries := []string{}
+	for {
+		e, err := gc.Next()
+		if err == io.EOF {
+			break
+		}
+
+		assert.Nil(t, err)
+		summaries = append(summaries, e.Summary)
+	}
+
+	assert.Equal(t, []string{
+		"Weekly sync (afternoon)",
+		"Weekly sync (moved once)",
+		"Weekly sync",
+		"Weekly sync",
+		"Weekly sync (afternoon)",
+		"Weekly sync (afternoon)",
+	}, summaries)
+	assert.Empty(t, gc.Events)
+
+	e, err := gc.Next()
+	assert.Nil(t, e)
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_NextFailFeed(t *testing.T) {
+	gc := NewParser(strings.NewReader(invalidICS))
+
+	_, err := gc.Next()
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+const pathologicalICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:secondly@gocal
+DTSTAMP:20000101T000000Z
+DTSTART:20000101T000000Z
+DTEND:20000101T000001Z
+RRULE:FREQ=SECONDLY
+END:VEVENT
+END:VCALENDAR`
+
+func Test_ParseContextDeadline(t *testing.T) {
+	start, end := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	gc := NewParser(strings.NewReader(pathologicalICS))
+	gc.Start, gc.End = &start, &end
+
+	before := time.Now()
+	err := gc.ParseContext(ctx)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(before) < time.Second)
+}
+
+func Test_ParseContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	gc := NewParser(strings.NewReader(ics))
+	err := gc.ParseContext(ctx)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Empty(t, gc.Events)
+}
+
+const parseErrorICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:error@gocal
+DESCRIPTION:A description
+  folded over
+  three lines
+DTSTART;TZID=Europe/Paris:2024-01
+ -05T09:00:00
+DTSTAMP:20240101T000000Z
+END:VEVENT
+END:VCALENDAR`
+
+func Test_ParseError(t *testing.T) {
+	gc := NewParser(strings.NewReader(parseErrorICS))
+	err := gc.Parse()
+
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 7, perr.Line)
+	assert.Equal(t, "DTSTART", perr.Key)
+	assert.Equal(t, "DTSTART;TZID=Europe/Paris:2024-01-05T09:00:00", perr.Text)
+	assert.Equal(t, "error@gocal", perr.UID)
+	assert.NotNil(t, errors.Unwrap(err))
+	assert.True(t, strings.HasPrefix(err.Error(), "gocal error: line 7, DTSTART, UID error@gocal: could not parse"))
+}
+
+func Test_ParseErrorOnComponentEnd(t *testing.T) {
+	gc := NewParser(strings.NewReader(invalidICS))
+	err := gc.Parse()
+
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "END", perr.Key)
+	assert.Equal(t, "END:VEVENT", perr.Text)
+	assert.Equal(t, 7, perr.Line)
+}
+
+func Test_ParseErrorDuplicate(t *testing.T) {
+	gc := NewParser(strings.NewReader(dupsICS))
+	err := gc.Parse()
+
+	var dup DuplicateAttributeError
+	assert.True(t, errors.As(err, &dup))
+	assert.Equal(t, "UID", dup.Key)
+}
+
+const warningsICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTAMP:20240101T000000Z
+UID:exdate@gocal
+DTSTART:20240105T090000Z
+DTEND:20240105T100000Z
+RRULE:FREQ=DAILY;COUNT=2
+EXDATE:2024-01-06
+END:VEVENT
+BEGIN:VEVENT
+DTSTAMP:20240101T000000Z
+UID:tz@gocal
+DTSTART;TZID=Nowhere/Unknown:20240110T090000
+DTEND;TZID=Nowhere/Unknown:20240110T100000
+END:VEVENT
+NOT A CONTENT LINE
+END:VCALENDAR`
+
+func Test_WarningsFailEvent(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(warningsICS))
+	gc.Start, gc.End = &start, &end
+	gc.Strict.Mode = StrictModeFailEvent
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 1)
+	assert.Equal(t, "tz@gocal", gc.Events[0].Uid)
+	assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), *gc.Events[0].Start)
+
+	assert.Len(t, gc.Warnings, 4)
+
+	assert.Equal(t, WarningDroppedAttribute, gc.Warnings[0].Kind)
+	assert.Equal(t, "EXDATE", gc.Warnings[0].Key)
+	assert.Equal(t, 8, gc.Warnings[0].Line)
+	assert.Equal(t, "exdate@gocal", gc.Warnings[0].UID)
+
+	assert.Equal(t, WarningSkippedComponent, gc.Warnings[1].Kind)
+	assert.Equal(t, 9, gc.Warnings[1].Line)
+	assert.Equal(t, "exdate@gocal", gc.Warnings[1].UID)
+
+	assert.Equal(t, WarningTimezoneFallback, gc.Warnings[2].Kind)
+	assert.Equal(t, 13, gc.Warnings[2].Line)
+	assert.Contains(t, gc.Warnings[2].Error(), "Nowhere/Unknown")
+
+	// The fallback is only reported once per TZID, not again for DTEND
+	assert.Equal(t, WarningUnparseableLine, gc.Warnings[3].Kind)
+	assert.Equal(t, 16, gc.Warnings[3].Line)
+	assert.Equal(t, "NOT A CONTENT LINE", gc.Warnings[3].Text)
+}
+
+func Test_WarningsFailAttribute(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(warningsICS))
+	gc.Start, gc.End = &start, &end
+	gc.Strict.Mode = StrictModeFailAttribute
+
+	assert.Nil(t, gc.Parse())
+	// Recurring instances are only returned once the whole feed is parsed
+	assert.Len(t, gc.Events, 3)
+	assert.True(t, gc.Events[0].Valid)
+	assert.Equal(t, "exdate@gocal", gc.Events[1].Uid)
+	assert.False(t, gc.Events[1].Valid)
+	assert.Len(t, gc.Warnings, 3)
+	assert.Equal(t, WarningDroppedAttribute, gc.Warnings[0].Kind)
+	assert.Equal(t, WarningTimezoneFallback, gc.Warnings[1].Kind)
+	assert.Equal(t, WarningUnparseableLine, gc.Warnings[2].Kind)
+}
+
+func Test_WarningsPartialList(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	ics := strings.Replace(warningsICS, "EXDATE:2024-01-06", "EXDATE:20240106T090000Z,2024-01-07", 1)
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+	gc.Strict.Mode = StrictModeFailAttribute
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, WarningDroppedAttribute, gc.Warnings[0].Kind)
+	assert.Equal(t, "EXDATE", gc.Warnings[0].Key)
+
+	// The valid value is dropped along with the invalid one
+	assert.Len(t, gc.Events, 3)
+	assert.Equal(t, "exdate@gocal", gc.Events[1].Uid)
+	assert.Len(t, gc.Events[1].ExcludeDates, 0)
+	assert.Equal(t, time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC), *gc.Events[2].Start)
+}
+
+func Test_WarningsFailFeed(t *testing.T) {
+	gc := NewParser(strings.NewReader(warningsICS))
+	err := gc.Parse()
+
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "EXDATE", perr.Key)
+}
+
+func longLineICS(size int) string {
+	data := strings.Repeat("QUJD", size/4)
+
+	var folded strings.Builder
+	folded.WriteString("ATTACH;ENCODING=BASE64;VALUE=BINARY:")
+	for len(data) > 74 {
+		folded.WriteString(data[:74] + "\r\n ")
+		data = data[74:]
+	}
+	folded.WriteString(data)
+
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTAMP:20240101T000000Z\r\nUID:long@gocal\r\n" + folded.String() +
+		"\r\nDTSTART:20240105T090000Z\r\nDTEND:20240105T100000Z\r\nEND:VEVENT\r\nBEGIN:VEVENT\r\nDTSTAMP:20240101T000000Z\r\n" +
+		"UID:after@gocal\r\nDTSTART:20240106T090000Z\r\nDTEND:20240106T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func Test_LongLines(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(longLineICS(256 * 1024)))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 2)
+	assert.Len(t, gc.Events[0].Attachments[0].Value, 256*1024)
+	assert.Equal(t, "after@gocal", gc.Events[1].Uid)
+}
+
+func Test_LongLinesTooLong(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(longLineICS(256 * 1024)))
+	gc.Start, gc.End = &start, &end
+	gc.MaxLineSize = 128 * 1024
+	err := gc.Parse()
+
+	var perr *ParseError
+	assert.True(t, errors.Is(err, bufio.ErrTooLong))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 5, perr.Line)
+	assert.Len(t, gc.Events, 0)
+}
+
+const lateVTimezoneICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:late@gocal
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Custom Office:20240320T090000
+DTEND;TZID=Custom Office:20240320T100000
+END:VEVENT
+BEGIN:VTIMEZONE
+TZID:Custom Office
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+END:VCALENDAR`
+
+func Test_VTimezoneAfterEvents(t *testing.T) {
+	gc := NewParser(strings.NewReader(lateVTimezoneICS))
+	gc.SkipBounds = true
+	err := gc.Parse()
+
+	assert.Nil(t, err)
+	assert.Len(t, gc.Events, 1)
+	assert.Equal(t, time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC), *gc.Events[0].Start)
+	assert.Len(t, gc.Timezones, 1)
+
+	assert.Len(t, gc.Warnings, 2)
+	assert.Equal(t, WarningTimezoneFallback, gc.Warnings[0].Kind)
+	assert.Equal(t, WarningTimezoneFallback, gc.Warnings[1].Kind)
+	assert.Equal(t, 15, gc.Warnings[1].Line)
+	assert.Contains(t, gc.Warnings[1].Error(), "defined after")
+}
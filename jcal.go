@@ -0,0 +1,284 @@
+package gocal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JCalEncoder writes calendars in the jCal format, as per RFC7265.
+type JCalEncoder struct {
+	w      io.Writer
+	ProdID string
+}
+
+func NewJCalEncoder(w io.Writer) *JCalEncoder {
+	return &JCalEncoder{w: w, ProdID: DefaultProdID}
+}
+
+// Encode writes a whole calendar, the same way Encoder.Encode does.
+func (enc *JCalEncoder) Encode(gc *Gocal) error {
+	return json.NewEncoder(enc.w).Encode(jcalComponent(calendarComponent(gc, enc.ProdID)))
+}
+
+// EncodeEvent writes a single vevent component.
+func (enc *JCalEncoder) EncodeEvent(e Event) error {
+	return json.NewEncoder(enc.w).Encode(jcalComponent(eventComponent(e, false)))
+}
+
+// NewJCalParser creates a parser reading a jCal document. Its properties go
+// through the same handling as iCalendar feeds, so that the resulting events
+// are identical, under the same strict and duplicate modes.
+func NewJCalParser(r io.Reader) *Gocal {
+	gc := NewParser(r)
+	gc.decode = func() ([]*Line, error) {
+		return decodeJCal(r)
+	}
+
+	return gc
+}
+
+func jcalComponent(c *component) []interface{} {
+	props := make([]interface{}, 0, len(c.Properties))
+	for _, l := range c.Properties {
+		props = append(props, jcalProperty(toProperty(l)))
+	}
+
+	comps := make([]interface{}, 0, len(c.Components))
+	for _, sub := range c.Components {
+		comps = append(comps, jcalComponent(sub))
+	}
+
+	return []interface{}{strings.ToLower(c.Name), props, comps}
+}
+
+func jcalProperty(p property) []interface{} {
+	out := []interface{}{p.Name, p.Params, p.Type}
+
+	switch {
+	case p.Type == "recur":
+		out = append(out, jcalRecur(p.Recur))
+	case p.Name == "geo":
+		values := make([]interface{}, len(p.Values))
+		for idx, v := range p.Values {
+			values[idx] = jcalNumber(v)
+		}
+		out = append(out, values)
+	case p.Type == "integer" || p.Type == "float":
+		for _, v := range p.Values {
+			out = append(out, jcalNumber(v))
+		}
+	default:
+		for _, v := range p.Values {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// jcalNumber writes numeric values as JSON numbers, as long as they are valid.
+func jcalNumber(v string) interface{} {
+	var n json.Number
+	if err := json.Unmarshal([]byte(v), &n); err != nil {
+		return v
+	}
+
+	return n
+}
+
+// jcalRecur is a recurrence rule, written as an object whose members keep the
+// order of the original rule.
+type jcalRecur []recurPart
+
+func (r jcalRecur) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("{")
+	for idx, part := range r {
+		if idx > 0 {
+			b.WriteString(",")
+		}
+
+		name, _ := json.Marshal(part.Name)
+		b.Write(name)
+		b.WriteString(":")
+
+		values := make([]interface{}, len(part.Values))
+		for vidx, v := range part.Values {
+			values[vidx] = v
+			if numericRecurParts[part.Name] {
+				values[vidx] = jcalNumber(v)
+			}
+		}
+
+		var (
+			value []byte
+			err   error
+		)
+		if len(values) == 1 {
+			value, err = json.Marshal(values[0])
+		} else {
+			value, err = json.Marshal(values)
+		}
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteString("}")
+
+	return b.Bytes(), nil
+}
+
+// jsonMember is a member of a JSON object, decoded in order.
+type jsonMember struct {
+	Name  string
+	Value interface{}
+}
+
+// decodeJSON decodes a JSON value like json.Unmarshal would, except objects are
+// decoded to ordered lists of members and numbers to json.Number.
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('['):
+		values := make([]interface{}, 0)
+		for dec.More() {
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		_, err := dec.Token()
+		return values, err
+	case json.Delim('{'):
+		members := make([]jsonMember, 0)
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, jsonMember{Name: fmt.Sprint(name), Value: v})
+		}
+		_, err := dec.Token()
+		return members, err
+	}
+
+	return token, nil
+}
+
+func decodeJCal(r io.Reader) ([]*Line, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	doc, err := decodeJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode jCal: %w", err)
+	}
+
+	lines := make([]*Line, 0)
+	if err := decodeJCalComponent(doc, &lines); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func decodeJCalComponent(doc interface{}, lines *[]*Line) error {
+	c, ok := doc.([]interface{})
+	if !ok || len(c) != 3 {
+		return fmt.Errorf("could not decode jCal component: %v", doc)
+	}
+
+	name, ok := c[0].(string)
+	props, pok := c[1].([]interface{})
+	comps, cok := c[2].([]interface{})
+	if !ok || !pok || !cok {
+		return fmt.Errorf("could not decode jCal component: %v", doc)
+	}
+
+	name = strings.ToUpper(name)
+	*lines = append(*lines, &Line{Key: "BEGIN", Value: name})
+
+	for _, prop := range props {
+		p, err := decodeJCalProperty(prop)
+		if err != nil {
+			return err
+		}
+
+		*lines = append(*lines, fromProperty(p))
+	}
+
+	for _, sub := range comps {
+		if err := decodeJCalComponent(sub, lines); err != nil {
+			return err
+		}
+	}
+
+	*lines = append(*lines, &Line{Key: "END", Value: name})
+
+	return nil
+}
+
+func decodeJCalProperty(prop interface{}) (property, error) {
+	p := property{Params: make(map[string]string)}
+
+	tokens, ok := prop.([]interface{})
+	if !ok || len(tokens) < 4 {
+		return p, fmt.Errorf("could not decode jCal property: %v", prop)
+	}
+
+	name, nok := tokens[0].(string)
+	params, pok := tokens[1].([]jsonMember)
+	ty, tok := tokens[2].(string)
+	if !nok || !pok || !tok {
+		return p, fmt.Errorf("could not decode jCal property: %v", prop)
+	}
+
+	p.Name, p.Type = name, ty
+
+	for _, param := range params {
+		p.Params[param.Name] = strings.Join(jcalStrings(param.Value), ",")
+	}
+
+	for _, value := range tokens[3:] {
+		if members, ok := value.([]jsonMember); ok && ty == "recur" {
+			for _, m := range members {
+				p.Recur = append(p.Recur, recurPart{Name: m.Name, Values: jcalStrings(m.Value)})
+			}
+			continue
+		}
+
+		p.Values = append(p.Values, jcalStrings(value)...)
+	}
+
+	return p, nil
+}
+
+// jcalStrings flattens a scalar or structured jCal value into strings.
+func jcalStrings(v interface{}) []string {
+	switch value := v.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			values = append(values, jcalStrings(item)...)
+		}
+		return values
+	case nil:
+		return []string{""}
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
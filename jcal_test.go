@@ -0,0 +1,99 @@
+package gocal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JCalRoundTrip(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewJCalEncoder(&out).Encode(gc))
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	rgc := NewJCalParser(&out)
+	rgc.Start, rgc.End = &start, &end
+	assert.Nil(t, rgc.Parse())
+
+	for idx := range rgc.Events {
+		rgc.Events[idx].delayed = nil
+	}
+	for idx := range rgc.Todos {
+		rgc.Todos[idx].delayed = nil
+	}
+
+	// jCal parameters are not quoted, quotes are only restored where needed
+	gc.Events[0].Attendees[0].CustomAttributes["X-RESPONSE-COMMENT"] = "Not interested"
+
+	assert.Equal(t, gc.Method, rgc.Method)
+	assert.Equal(t, gc.Events, rgc.Events)
+	assert.Equal(t, gc.Todos, rgc.Todos)
+	assert.Equal(t, gc.Journals, rgc.Journals)
+	assert.Equal(t, gc.Timezones["Custom Zone"].Observances, rgc.Timezones["Custom Zone"].Observances)
+}
+
+func Test_JCalEncodeEvent(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewJCalEncoder(&out).EncodeEvent(*gc.Events[2].Series.Master))
+
+	var doc []interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, "vevent", doc[0])
+
+	props := map[string][]interface{}{}
+	for _, p := range doc[1].([]interface{}) {
+		props[p.([]interface{})[0].(string)] = p.([]interface{})
+	}
+
+	assert.Equal(t, []interface{}{"dtstart", map[string]interface{}{"tzid": "Custom Zone"}, "date-time", "2024-01-08T09:00:00"}, props["dtstart"])
+	assert.Equal(t, []interface{}{"duration", map[string]interface{}{}, "duration", "PT1H"}, props["duration"])
+	assert.Equal(t, []interface{}{"rrule", map[string]interface{}{}, "recur", map[string]interface{}{"freq": "WEEKLY", "byday": "MO"}}, props["rrule"])
+	assert.Contains(t, out.String(), `"recur",{"freq":"WEEKLY","byday":"MO"}`)
+}
+
+func Test_JCalParse(t *testing.T) {
+	doc := `["vcalendar", [["version", {}, "text", "2.0"]], [
+    ["vevent", [
+      ["uid", {}, "text", "jcal@gocal"],
+      ["dtstamp", {}, "date-time", "2024-01-01T00:00:00Z"],
+      ["dtstart", {}, "date", "2024-01-05"],
+      ["summary", {}, "text", "Comma, semicolon; and backslash \\"],
+      ["categories", {}, "text", "One", "Two, three"],
+      ["geo", {}, "float", [48.85, 2.35]],
+      ["rrule", {}, "recur", {"freq": "DAILY", "count": 2}],
+      ["x-custom", {}, "unknown", "Custom"]
+    ], []]
+  ]]`
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewJCalParser(strings.NewReader(doc))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 2)
+
+	e := gc.Events[1]
+	assert.Equal(t, "jcal@gocal", e.Uid)
+	assert.Equal(t, time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), *e.Start)
+	assert.Equal(t, "Comma, semicolon; and backslash \\", e.Summary)
+	assert.Equal(t, "FREQ=DAILY;COUNT=2", e.RecurrenceRuleString)
+	assert.Equal(t, []string{"One", "Two, three"}, e.Categories)
+	assert.Equal(t, 48.85, e.Geo.Lat)
+	assert.Equal(t, "Custom", e.CustomAttributes["X-CUSTOM"])
+}
+
+func Test_JCalInvalid(t *testing.T) {
+	gc := NewJCalParser(strings.NewReader(`["vcalendar", [["version"]], []]`))
+
+	assert.NotNil(t, gc.Parse())
+}
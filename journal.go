@@ -0,0 +1,118 @@
+package gocal
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/apognu/gocal/parser"
+)
+
+func (gc *Gocal) parseJournal(l *Line) error {
+	// If this is nil, that means we did not get a BEGIN:VJOURNAL
+	if gc.journalBuffer == nil {
+		return nil
+	}
+
+	switch l.Key {
+	case "UID":
+		if err := resolve(gc, l, &gc.journalBuffer.Uid, resolveString, nil); err != nil {
+			return err
+		}
+	case "SUMMARY":
+		if err := resolve(gc, l, &gc.journalBuffer.Summary, resolveString, nil); err != nil {
+			return err
+		}
+	case "DESCRIPTION":
+		// Unlike other components, journal entries can hold several descriptions
+		gc.journalBuffer.Descriptions = append(gc.journalBuffer.Descriptions, l.Value)
+	case "DTSTART":
+		if err := resolve(gc, l, &gc.journalBuffer.Start, resolveDate, func(gc *Gocal, out *time.Time) {
+			gc.journalBuffer.RawStart = RawDate{Value: l.Value, Params: l.Params}
+		}); err != nil {
+			return err
+		}
+	case "DTSTAMP":
+		if err := resolve(gc, l, &gc.journalBuffer.Stamp, resolveDate, nil); err != nil {
+			return err
+		}
+	case "CREATED":
+		if err := resolve(gc, l, &gc.journalBuffer.Created, resolveDate, nil); err != nil {
+			return err
+		}
+	case "LAST-MODIFIED":
+		if err := resolve(gc, l, &gc.journalBuffer.LastModified, resolveDate, nil); err != nil {
+			return err
+		}
+	case "RRULE":
+		if gc.journalBuffer.RecurrenceRule != nil {
+			return NewDuplicateAttribute(l.Key, l.Value)
+		}
+
+		if gc.journalBuffer.RecurrenceRule == nil || gc.Duplicate.Mode == DuplicateModeKeepLast {
+			rule, err := parser.ParseRecurrenceRule(l.Value)
+			if err != nil {
+				return err
+			}
+
+			gc.journalBuffer.IsRecurring = true
+			gc.journalBuffer.RecurrenceRule = rule
+			gc.journalBuffer.RecurrenceRuleString = l.Value
+		}
+	case "RECURRENCE-ID":
+		if err := resolve(gc, l, &gc.journalBuffer.RecurrenceID, resolveDate, func(gc *Gocal, out *time.Time) {
+			gc.journalBuffer.RawRecurrenceID = RawDate{Value: l.Value, Params: l.Params}
+		}); err != nil {
+			return err
+		}
+	case "EXDATE":
+		dates, err := gc.parseDateList(l)
+		if err != nil {
+			return err
+		}
+		gc.journalBuffer.ExcludeDates = append(gc.journalBuffer.ExcludeDates, dates...)
+	case "SEQUENCE":
+		gc.journalBuffer.Sequence, _ = strconv.Atoi(l.Value)
+	case "STATUS":
+		if err := resolve(gc, l, &gc.journalBuffer.Status, resolveString, nil); err != nil {
+			return err
+		}
+	case "ORGANIZER":
+		if err := resolve(gc, l, &gc.journalBuffer.Organizer, resolveOrganizer, nil); err != nil {
+			return err
+		}
+	case "ATTENDEE":
+		gc.journalBuffer.Attendees = append(gc.journalBuffer.Attendees, parseAttendee(l))
+	case "ATTACH":
+		gc.journalBuffer.Attachments = append(gc.journalBuffer.Attachments, parseAttachment(l))
+	case "CATEGORIES":
+		gc.journalBuffer.Categories = append(gc.journalBuffer.Categories, l.values()...)
+	case "URL":
+		gc.journalBuffer.URL = l.Value
+	case "COMMENT":
+		gc.journalBuffer.Comment = l.Value
+	case "CLASS":
+		gc.journalBuffer.Class = l.Value
+	default:
+		parseCustomAttribute(l, &gc.journalBuffer.CustomAttributes)
+	}
+
+	return nil
+}
+
+func (gc *Gocal) checkJournal() error {
+	if gc.journalBuffer.Uid == "" {
+		gc.journalBuffer.Valid = false
+		return fmt.Errorf("could not parse journal without UID")
+	}
+	if gc.journalBuffer.Stamp == nil {
+		gc.journalBuffer.Valid = false
+		return fmt.Errorf("could not parse journal without DTSTAMP")
+	}
+	if gc.journalBuffer.IsRecurring && gc.journalBuffer.Start == nil {
+		gc.journalBuffer.Valid = false
+		return fmt.Errorf("could not parse recurring journal without DTSTART")
+	}
+
+	return nil
+}
@@ -36,10 +36,12 @@ func ParseParameters(p string) (string, map[string]string) {
 	return tokens[0], parameters
 }
 
-func UnescapeString(l string) string {
-	l = strings.Replace(l, `\\`, `\`, -1)
-	l = strings.Replace(l, `\;`, `;`, -1)
-	l = strings.Replace(l, `\,`, `,`, -1)
+// textUnescaper unescapes TEXT values in a single pass, so that escaped
+// backslashes are not mistaken for the start of another escape sequence.
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, `;`, `\,`, `,`, `\n`, "\n", `\N`, "\n")
 
-	return l
+// UnescapeString unescapes a TEXT value, as per RFC5545, 3.3.11. Backslashes
+// that do not start an escape sequence are kept as is.
+func UnescapeString(l string) string {
+	return textUnescaper.Replace(l)
 }
@@ -19,4 +19,8 @@ func Test_UnescapeString(t *testing.T) {
 	l = UnescapeString(l)
 
 	assert.Equal(t, `Hello, world; lorem \ipsum.`, l)
+
+	assert.Equal(t, "First line\nSecond line\nThird", UnescapeString(`First line\nSecond line\NThird`))
+	assert.Equal(t, `C:\new\, not a newline`, UnescapeString(`C:\\new\\\, not a newline`))
+	assert.Equal(t, `Unknown \escape`, UnescapeString(`Unknown \escape`))
 }
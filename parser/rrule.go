@@ -1,7 +1,328 @@
 package parser
 
-func ParseRecurrenceRule(v string) (map[string]string, error) {
-	_, params := ParseRecurrenceParams(v)
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
-	return params, nil
+var frequencies = map[string]bool{
+	"SECONDLY": true,
+	"MINUTELY": true,
+	"HOURLY":   true,
+	"DAILY":    true,
+	"WEEKLY":   true,
+	"MONTHLY":  true,
+	"YEARLY":   true,
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum is a day of the week of a BYDAY rule part, optionally preceded by
+// an ordinal such as 1 for the first Monday, or -1 for the last one. N is 0
+// when there is no ordinal.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayNames[w.Day]
+	}
+
+	return strconv.Itoa(w.N) + weekdayNames[w.Day]
+}
+
+// RRule is a recurrence rule, as found in RRULE and EXRULE properties. See
+// RFC5545, 3.3.10.
+type RRule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    *time.Time
+	// UNTIL is a DATE rather than a DATE-TIME
+	UntilDate  bool
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByYearDay  []int
+	ByWeekNo   []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  *time.Weekday
+}
+
+// ParseRecurrenceRule parses and validates a recurrence rule. INTERVAL
+// defaults to 1, and extension X-* parts are ignored.
+func ParseRecurrenceRule(v string) (*RRule, error) {
+	r := &RRule{Interval: 1}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(v, ";") {
+		// Some producers end rules with a semicolon
+		if part == "" {
+			continue
+		}
+
+		tokens := strings.SplitN(part, "=", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("could not parse recurrence rule part: %s", part)
+		}
+
+		name, value := strings.ToUpper(tokens[0]), tokens[1]
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate recurrence rule part: %s", name)
+		}
+		seen[name] = true
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if !frequencies[r.Freq] {
+				err = fmt.Errorf("unknown frequency %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(value)
+		case "COUNT":
+			r.Count, err = parsePositive(value)
+		case "UNTIL":
+			r.Until, r.UntilDate, err = parseUntil(value)
+		case "BYSECOND":
+			r.BySecond, err = parseIntList(value, 0, 60, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseIntList(value, 0, 59, false)
+		case "BYHOUR":
+			r.ByHour, err = parseIntList(value, 0, 23, false)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdayList(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(value, 1, 31, true)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseIntList(value, 1, 366, true)
+		case "BYWEEKNO":
+			r.ByWeekNo, err = parseIntList(value, 1, 53, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseIntList(value, 1, 12, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(value, 1, 366, true)
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("unknown weekday %s", value)
+			}
+			r.WeekStart = &day
+		default:
+			if !strings.HasPrefix(name, "X-") {
+				err = fmt.Errorf("unknown part")
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse recurrence rule %s: %w", name, err)
+		}
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// validate checks the constraints between rule parts.
+func (r *RRule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("FREQ is required")
+	}
+	if r.Count != 0 && r.Until != nil {
+		return fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != "MONTHLY" && r.Freq != "YEARLY" {
+			return fmt.Errorf("BYDAY ordinals are only allowed with MONTHLY and YEARLY frequencies")
+		}
+		if day.N != 0 && r.Freq == "YEARLY" && len(r.ByWeekNo) > 0 {
+			return fmt.Errorf("BYDAY ordinals are not allowed along BYWEEKNO")
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == "WEEKLY" {
+		return fmt.Errorf("BYMONTHDAY is not allowed with the WEEKLY frequency")
+	}
+	if len(r.ByYearDay) > 0 && (r.Freq == "DAILY" || r.Freq == "WEEKLY" || r.Freq == "MONTHLY") {
+		return fmt.Errorf("BYYEARDAY is not allowed with the %s frequency", r.Freq)
+	}
+	if len(r.ByWeekNo) > 0 && r.Freq != "YEARLY" {
+		return fmt.Errorf("BYWEEKNO is only allowed with the YEARLY frequency")
+	}
+	if len(r.BySetPos) > 0 && len(r.BySecond)+len(r.ByMinute)+len(r.ByHour)+len(r.ByDay)+len(r.ByMonthDay)+len(r.ByYearDay)+len(r.ByWeekNo)+len(r.ByMonth) == 0 {
+		return fmt.Errorf("BYSETPOS requires another BYxxx rule part")
+	}
+
+	return nil
+}
+
+// String returns the canonical form of the rule, with its parts in the order
+// of RFC5545, 3.3.10, and INTERVAL omitted when it is 1.
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Until != nil {
+		switch {
+		case r.UntilDate:
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		case r.Until.Location() == time.UTC:
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405Z"))
+		default:
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+		}
+	}
+	if r.Count != 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	parts = appendIntList(parts, "BYSECOND", r.BySecond)
+	parts = appendIntList(parts, "BYMINUTE", r.ByMinute)
+	parts = appendIntList(parts, "BYHOUR", r.ByHour)
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for idx, day := range r.ByDay {
+			days[idx] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	parts = appendIntList(parts, "BYMONTHDAY", r.ByMonthDay)
+	parts = appendIntList(parts, "BYYEARDAY", r.ByYearDay)
+	parts = appendIntList(parts, "BYWEEKNO", r.ByWeekNo)
+	parts = appendIntList(parts, "BYMONTH", r.ByMonth)
+	parts = appendIntList(parts, "BYSETPOS", r.BySetPos)
+
+	if r.WeekStart != nil {
+		parts = append(parts, "WKST="+weekdayNames[*r.WeekStart])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func parsePositive(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("%d is not positive", n)
+	}
+
+	return n, nil
+}
+
+// parseUntil parses an UNTIL date, which is either a DATE, a UTC DATE-TIME or,
+// for rules of floating events, a local DATE-TIME.
+func parseUntil(v string) (*time.Time, bool, error) {
+	var (
+		t   time.Time
+		err error
+	)
+
+	switch {
+	case len(v) == 8:
+		t, err = time.Parse("20060102", v)
+		return &t, true, err
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse("20060102T150405Z", v)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", v, time.Local)
+	}
+
+	return &t, false, err
+}
+
+// parseIntList parses a list of integers between min and max, or between -max
+// and -min if negative values are allowed.
+func parseIntList(v string, min, max int, negative bool) ([]int, error) {
+	tokens := strings.Split(v, ",")
+	values := make([]int, len(tokens))
+
+	for idx, token := range tokens {
+		n, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, err
+		}
+
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d is out of range", n)
+		}
+
+		values[idx] = n
+	}
+
+	return values, nil
+}
+
+func parseWeekdayList(v string) ([]WeekdayNum, error) {
+	tokens := strings.Split(v, ",")
+	values := make([]WeekdayNum, len(tokens))
+
+	for idx, token := range tokens {
+		if len(token) < 2 {
+			return nil, fmt.Errorf("unknown weekday %s", token)
+		}
+
+		day, ok := weekdays[strings.ToUpper(token[len(token)-2:])]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %s", token)
+		}
+
+		n := 0
+		if ordinal := strings.TrimPrefix(token[:len(token)-2], "+"); ordinal != "" {
+			var err error
+
+			if n, err = strconv.Atoi(ordinal); err != nil {
+				return nil, err
+			}
+			if n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("%d is out of range", n)
+			}
+		}
+
+		values[idx] = WeekdayNum{N: n, Day: day}
+	}
+
+	return values, nil
+}
+
+func appendIntList(parts []string, name string, values []int) []string {
+	if len(values) == 0 {
+		return parts
+	}
+
+	tokens := make([]string, len(values))
+	for idx, v := range values {
+		tokens[idx] = strconv.Itoa(v)
+	}
+
+	return append(parts, name+"="+strings.Join(tokens, ","))
 }
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRecurrenceRule(t *testing.T) {
+	r, err := ParseRecurrenceRule("FREQ=MONTHLY;INTERVAL=2;BYDAY=1MO,-1FR,+2TU,WE;BYSETPOS=-1;UNTIL=20240601T090000Z;WKST=SU;")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "MONTHLY", r.Freq)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), *r.Until)
+	assert.False(t, r.UntilDate)
+	assert.Equal(t, []WeekdayNum{{N: 1, Day: time.Monday}, {N: -1, Day: time.Friday}, {N: 2, Day: time.Tuesday}, {Day: time.Wednesday}}, r.ByDay)
+	assert.Equal(t, []int{-1}, r.BySetPos)
+	assert.Equal(t, time.Sunday, *r.WeekStart)
+
+	r, err = ParseRecurrenceRule("FREQ=YEARLY;BYMONTH=1,2;BYMONTHDAY=-1;BYHOUR=8,20;BYMINUTE=30;BYSECOND=0;COUNT=10;X-NAME=Value")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, r.Interval)
+	assert.Equal(t, 10, r.Count)
+	assert.Nil(t, r.Until)
+	assert.Equal(t, []int{1, 2}, r.ByMonth)
+	assert.Equal(t, []int{-1}, r.ByMonthDay)
+	assert.Equal(t, []int{8, 20}, r.ByHour)
+	assert.Equal(t, []int{30}, r.ByMinute)
+	assert.Equal(t, []int{0}, r.BySecond)
+
+	r, err = ParseRecurrenceRule("FREQ=DAILY;UNTIL=20240601")
+
+	assert.Nil(t, err)
+	assert.True(t, r.UntilDate)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), *r.Until)
+}
+
+func Test_ParseRecurrenceRuleInvalid(t *testing.T) {
+	rules := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=SOMETIMES",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;BYMINUTE=60",
+		"FREQ=DAILY;BYSECOND=61",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=54MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYYEARDAY=1",
+		"FREQ=MONTHLY;BYWEEKNO=1",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=YEARLY;BYSETPOS=1",
+		"FREQ=DAILY;WKST=XX",
+		"FREQ=DAILY;BYEASTER=1",
+		"FREQ",
+	}
+
+	for _, rule := range rules {
+		_, err := ParseRecurrenceRule(rule)
+
+		assert.NotNil(t, err, rule)
+	}
+}
+
+func Test_RecurrenceRuleString(t *testing.T) {
+	tests := map[string]string{
+		"FREQ=WEEKLY;BYDAY=MO,TU;INTERVAL=2;COUNT=3":                          "FREQ=WEEKLY;COUNT=3;INTERVAL=2;BYDAY=MO,TU",
+		"freq=monthly;bysetpos=-1;byday=+1mo,-1fr;interval=1":                 "FREQ=MONTHLY;BYDAY=1MO,-1FR;BYSETPOS=-1",
+		"FREQ=YEARLY;UNTIL=20291104T060000Z;BYMONTH=11;BYDAY=1SU":             "FREQ=YEARLY;UNTIL=20291104T060000Z;BYDAY=1SU;BYMONTH=11",
+		"FREQ=DAILY;UNTIL=20240601;WKST=SU":                                   "FREQ=DAILY;UNTIL=20240601;WKST=SU",
+		"FREQ=DAILY;UNTIL=20240601T090000":                                    "FREQ=DAILY;UNTIL=20240601T090000",
+		"FREQ=YEARLY;BYWEEKNO=20;BYYEARDAY=-1;BYHOUR=9;BYMINUTE=0;BYSECOND=0": "FREQ=YEARLY;BYSECOND=0;BYMINUTE=0;BYHOUR=9;BYYEARDAY=-1;BYWEEKNO=20",
+	}
+
+	for rule, expected := range tests {
+		r, err := ParseRecurrenceRule(rule)
+
+		assert.Nil(t, err, rule)
+		assert.Equal(t, expected, r.String())
+	}
+}
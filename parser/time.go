@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,11 +13,38 @@ const (
 	TimeEnd
 )
 
-var (
-	TZMapper func(s string) (*time.Location, error)
-)
+// TZResolver resolves the TZID of a date into a location.
+type TZResolver interface {
+	Resolve(tzid string) (*time.Location, error)
+}
+
+// TZResolverFunc allows a function to be used as a TZResolver.
+type TZResolverFunc func(tzid string) (*time.Location, error)
+
+func (f TZResolverFunc) Resolve(tzid string) (*time.Location, error) {
+	return f(tzid)
+}
+
+// DefaultTZResolver resolves timezones through the package-level TZMapper.
+var DefaultTZResolver TZResolver = TZResolverFunc(func(tzid string) (*time.Location, error) {
+	if TZMapper == nil {
+		return nil, fmt.Errorf("no timezone mapper configured")
+	}
+
+	return TZMapper(tzid)
+})
+
+var TZMapper func(s string) (*time.Location, error)
 
 func ParseTime(s string, params map[string]string, ty int, allday bool, allDayTZ *time.Location) (*time.Time, error) {
+	return ParseTimeWithResolver(s, params, ty, allday, allDayTZ, DefaultTZResolver)
+}
+
+// ParseTimeWithResolver parses a date like ParseTime, resolving its TZID with
+// the given resolver first. If the resolver fails, LoadTimezone is tried, and
+// the date is considered UTC if both fail. Quotes around the TZID are removed
+// beforehand, so that resolvers are given the name of the timezone.
+func ParseTimeWithResolver(s string, params map[string]string, ty int, allday bool, allDayTZ *time.Location, resolver TZResolver) (*time.Time, error) {
 	var err error
 	var tz *time.Location
 
@@ -45,16 +73,16 @@ func ParseTime(s string, params map[string]string, ty int, allday bool, allDayTZ
 		// If string end in 'Z', timezone is UTC
 		format = "20060102T150405Z"
 		tz, _ = time.LoadLocation("UTC")
-	} else if params["TZID"] != "" {
+	} else if tzid := strings.Trim(params["TZID"], `"`); tzid != "" {
 		var err error
 
 		// If TZID param is given, parse in the timezone unless it is not valid
 		format = "20060102T150405"
-		if TZMapper != nil {
-			tz, err = TZMapper(params["TZID"])
+		if resolver != nil {
+			tz, err = resolver.Resolve(tzid)
 		}
-		if TZMapper == nil || err != nil {
-			tz, err = LoadTimezone(params["TZID"])
+		if resolver == nil || err != nil {
+			tz, err = LoadTimezone(tzid)
 		}
 
 		if err != nil {
@@ -71,21 +99,109 @@ func ParseTime(s string, params map[string]string, ty int, allday bool, allDayTZ
 	return &t, err
 }
 
-func ParseDuration(s string) (*time.Duration, error) {
+// NominalDuration is a duration as per RFC5545, 3.3.6, whose weeks and days
+// are nominal: they span as many calendar days whatever the DST changes in
+// between, while hours, minutes and seconds are exact. P1D and PT24H are thus
+// different durations.
+type NominalDuration struct {
+	Days int
+	Time time.Duration
+}
+
+func ParseNominalDuration(s string) (*NominalDuration, error) {
+	// Durations can be signed, as is common for alarm triggers (e.g. -PT15M)
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+
 	d, err := duration.FromString(s)
 	if err != nil {
 		return nil, err
 	}
-	dur := d.ToDuration()
+
+	days := d.Years*365 + d.Weeks*7 + d.Days
+	exact := time.Duration(d.Hours)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second
+
+	return &NominalDuration{Days: sign * days, Time: time.Duration(sign) * exact}, nil
+}
+
+// AddTo returns the given date moved by the duration, its days being added to
+// the calendar date before its exact time is added.
+func (d NominalDuration) AddTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.Days).Add(d.Time)
+}
+
+// Duration approximates the duration as elapsed time, with days of 24 hours.
+func (d NominalDuration) Duration() time.Duration {
+	return time.Duration(d.Days)*24*time.Hour + d.Time
+}
+
+func (d NominalDuration) String() string {
+	var b strings.Builder
+
+	days, t := d.Days, d.Time
+	if days < 0 || t < 0 {
+		b.WriteString("-")
+		days, t = -days, -t
+	}
+	b.WriteString("P")
+
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+
+	if t > 0 || days == 0 {
+		b.WriteString("T")
+		if h := t / time.Hour; h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+			t -= h * time.Hour
+		}
+		if m := t / time.Minute; m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+			t -= m * time.Minute
+		}
+		if s := t / time.Second; s > 0 || strings.HasSuffix(b.String(), "T") {
+			fmt.Fprintf(&b, "%dS", s)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseDuration parses a duration as elapsed time, with days of 24 hours. See
+// ParseNominalDuration for durations to be added to dates.
+func ParseDuration(s string) (*time.Duration, error) {
+	d, err := ParseNominalDuration(s)
+	if err != nil {
+		return nil, err
+	}
+
+	dur := d.Duration()
 	return &dur, nil
 }
 
+// LoadTimezone loads a timezone from its IANA name, whatever its case, or from
+// its Windows name (such as "Pacific Standard Time").
 func LoadTimezone(tzid string) (*time.Location, error) {
+	return LoadTimezoneWithWindowsZones(tzid, true)
+}
+
+// LoadTimezoneWithWindowsZones loads a timezone like LoadTimezone, mapping
+// Windows timezone names to IANA ones only if enabled.
+func LoadTimezoneWithWindowsZones(tzid string, enabled bool) (*time.Location, error) {
 	tz, err := time.LoadLocation(tzid)
 	if err == nil {
 		return tz, err
 	}
 
+	if enabled {
+		if iana, ok := windowsZones[tzid]; ok {
+			return time.LoadLocation(iana)
+		}
+	}
+
 	tokens := strings.Split(tzid, "_")
 	for idx, t := range tokens {
 		t = strings.ToLower(t)
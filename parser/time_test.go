@@ -145,3 +145,107 @@ func Test_ParseTimeAllDayOtherTimezone(t *testing.T) {
 	assert.Equal(t, 59, tiz.Minute())
 	assert.Equal(t, 59, tiz.Second())
 }
+
+func Test_ParseDuration(t *testing.T) {
+	d, err := ParseDuration("PT15M")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 15*time.Minute, *d)
+
+	d, err = ParseDuration("-P1DT2H")
+
+	assert.Nil(t, err)
+	assert.Equal(t, -26*time.Hour, *d)
+
+	d, err = ParseDuration("+PT30S")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, *d)
+}
+
+func Test_ParseNominalDuration(t *testing.T) {
+	d, err := ParseNominalDuration("P1D")
+
+	assert.Nil(t, err)
+	assert.Equal(t, NominalDuration{Days: 1}, *d)
+	assert.Equal(t, "P1D", d.String())
+
+	d, err = ParseNominalDuration("PT24H")
+
+	assert.Nil(t, err)
+	assert.Equal(t, NominalDuration{Time: 24 * time.Hour}, *d)
+	assert.Equal(t, "PT24H", d.String())
+
+	d, err = ParseNominalDuration("-P1DT1H30M")
+
+	assert.Nil(t, err)
+	assert.Equal(t, NominalDuration{Days: -1, Time: -90 * time.Minute}, *d)
+	assert.Equal(t, "-P1DT1H30M", d.String())
+
+	tz, _ := time.LoadLocation("Europe/Paris")
+	start := time.Date(2024, 3, 30, 9, 0, 0, 0, tz)
+
+	assert.Equal(t, time.Date(2024, 3, 31, 9, 0, 0, 0, tz), NominalDuration{Days: 1}.AddTo(start))
+	assert.Equal(t, time.Date(2024, 3, 31, 10, 0, 0, 0, tz), NominalDuration{Time: 24 * time.Hour}.AddTo(start))
+}
+
+func Test_WindowsTimezone(t *testing.T) {
+	data := map[string]string{
+		"Pacific Standard Time":   "America/Los_Angeles",
+		"W. Europe Standard Time": "Europe/Berlin",
+		"Tokyo Standard Time":     "Asia/Tokyo",
+	}
+
+	for in, exp := range data {
+		tz, err := LoadTimezone(in)
+
+		assert.Nil(t, err)
+		assert.Equal(t, exp, tz.String())
+	}
+
+	ti, _ := ParseTime("20150910T135212", map[string]string{"TZID": "Pacific Standard Time"}, TimeStart, false, time.UTC)
+	tz, _ := time.LoadLocation("America/Los_Angeles")
+
+	assert.Equal(t, tz, ti.Location())
+
+	ti, _ = ParseTime("20150910T135212", map[string]string{"TZID": `"Pacific Standard Time"`}, TimeStart, false, time.UTC)
+
+	assert.Equal(t, tz, ti.Location())
+
+	_, err := LoadTimezoneWithWindowsZones("Pacific Standard Time", false)
+	assert.NotNil(t, err)
+}
+
+func Test_WindowsZonesAreValid(t *testing.T) {
+	for windows, iana := range windowsZones {
+		_, err := time.LoadLocation(iana)
+		assert.Nil(t, err, windows)
+	}
+}
+
+func Test_ParseTimeWithResolver(t *testing.T) {
+	resolver := TZResolverFunc(func(s string) (*time.Location, error) {
+		if s == "test1" {
+			return time.LoadLocation("Asia/Tokyo")
+		}
+		return nil, fmt.Errorf("mapping not found")
+	})
+
+	ti, _ := ParseTimeWithResolver("20150910T135212", map[string]string{"TZID": "test1"}, TimeStart, false, time.UTC, resolver)
+	tz, _ := time.LoadLocation("Asia/Tokyo")
+
+	assert.Equal(t, tz, ti.Location())
+
+	ti, _ = ParseTimeWithResolver("20150910T135212", map[string]string{"TZID": `"test1"`}, TimeStart, false, time.UTC, resolver)
+
+	assert.Equal(t, tz, ti.Location())
+
+	ti, _ = ParseTimeWithResolver("20150910T135212", map[string]string{"TZID": "Europe/Paris"}, TimeStart, false, time.UTC, resolver)
+	tz, _ = time.LoadLocation("Europe/Paris")
+
+	assert.Equal(t, tz, ti.Location())
+
+	ti, _ = ParseTimeWithResolver("20150910T135212", map[string]string{"TZID": "test2"}, TimeStart, false, time.UTC, resolver)
+
+	assert.Equal(t, time.UTC, ti.Location())
+}
@@ -0,0 +1,404 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// TimezoneObservance is a STANDARD or DAYLIGHT sub-component of a VTIMEZONE,
+// holding the raw values of its properties.
+type TimezoneObservance struct {
+	Daylight        bool
+	Name            string
+	Start           string
+	OffsetFrom      string
+	OffsetTo        string
+	RecurrenceRule  string
+	RecurrenceDates []string
+}
+
+// Observances are expanded up to that year when synthesizing a location. Past
+// it, the location relies on a POSIX rule when the observances allow for one.
+const timezoneHorizon = 2100
+
+type transition struct {
+	when int64
+	zone int
+}
+
+type zone struct {
+	name   string
+	offset int
+	dst    bool
+}
+
+// ParseUTCOffset parses a UTC-OFFSET value (e.g. -0500 or +013045) into seconds.
+func ParseUTCOffset(s string) (int, error) {
+	if len(s) != 5 && len(s) != 7 {
+		return 0, fmt.Errorf("could not parse UTC offset: %s", s)
+	}
+
+	sign := 1
+	switch s[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("could not parse UTC offset: %s", s)
+	}
+
+	offset := 0
+	for idx, unit := range []int{3600, 60, 1} {
+		if 1+idx*2 >= len(s) {
+			break
+		}
+		v, err := strconv.Atoi(s[1+idx*2 : 3+idx*2])
+		if err != nil {
+			return 0, fmt.Errorf("could not parse UTC offset: %s", s)
+		}
+		offset += v * unit
+	}
+
+	return sign * offset, nil
+}
+
+// NewTimezoneLocation synthesizes a time.Location from the observances of a
+// VTIMEZONE component, so that offsets are correctly resolved for any instant.
+func NewTimezoneLocation(tzid string, observances []TimezoneObservance) (*time.Location, error) {
+	if len(observances) == 0 {
+		return nil, fmt.Errorf("timezone %s has no STANDARD or DAYLIGHT observance", tzid)
+	}
+
+	var (
+		zones       []zone
+		transitions []transition
+		firstOnset  *time.Time
+		firstFrom   int
+	)
+
+	for _, o := range observances {
+		from, err := ParseUTCOffset(o.OffsetFrom)
+		if err != nil {
+			return nil, err
+		}
+		to, err := ParseUTCOffset(o.OffsetTo)
+		if err != nil {
+			return nil, err
+		}
+
+		name := o.Name
+		if name == "" {
+			name = formatOffset(to)
+		}
+		zones = append(zones, zone{name: name, offset: to, dst: o.Daylight})
+		idx := len(zones) - 1
+
+		onsets, err := observanceOnsets(o, from)
+		if err != nil {
+			return nil, fmt.Errorf("timezone %s: %s", tzid, err)
+		}
+
+		for _, onset := range onsets {
+			transitions = append(transitions, transition{when: onset.Unix(), zone: idx})
+			if firstOnset == nil || onset.Before(*firstOnset) {
+				first := onset
+				firstOnset, firstFrom = &first, from
+			}
+		}
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].when < transitions[j].when
+	})
+
+	// Times before the first onset use the offset it transitions from. It is
+	// stored as its own, unreferenced zone so that Go picks it as the initial one.
+	initial := zone{name: formatOffset(firstFrom), offset: firstFrom}
+	for _, z := range zones {
+		if z.offset == firstFrom {
+			initial.name, initial.dst = z.name, z.dst
+			break
+		}
+	}
+
+	// The POSIX rule only holds if the latest transitions come from recurring
+	// observances, and not from a final one-off change (e.g. DST being abolished).
+	footer := ""
+	if len(observances) == 1 || observances[transitions[len(transitions)-1].zone].RecurrenceRule != "" {
+		footer = posixRule(observances)
+	}
+
+	data := encodeTZif(initial, zones, transitions, footer)
+
+	return time.LoadLocationFromTZData(tzid, data)
+}
+
+// observanceOnsets lists the UTC instants at which an observance comes into
+// effect, from its DTSTART, RRULE and RDATEs. All those are expressed as local
+// time in the offset in effect before the onset.
+func observanceOnsets(o TimezoneObservance, from int) ([]time.Time, error) {
+	loc := time.FixedZone("", from)
+
+	start, err := time.ParseInLocation("20060102T150405", o.Start, loc)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse observance DTSTART: %s", o.Start)
+	}
+
+	onsets := []time.Time{start}
+
+	if o.RecurrenceRule != "" {
+		rOption, err := rrule.StrToROptionInLocation(o.RecurrenceRule, loc)
+		if err != nil {
+			return nil, err
+		}
+		rOption.Dtstart = start
+
+		r, err := rrule.NewRRule(*rOption)
+		if err != nil {
+			return nil, err
+		}
+
+		horizon := time.Date(timezoneHorizon, 12, 31, 23, 59, 59, 0, loc)
+		for _, occ := range r.Between(start, horizon, false) {
+			onsets = append(onsets, occ)
+		}
+	}
+
+	for _, rdate := range o.RecurrenceDates {
+		format := "20060102T150405"
+		if strings.HasSuffix(rdate, "Z") {
+			format = "20060102T150405Z"
+		}
+
+		d, err := time.ParseInLocation(format, rdate, loc)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse observance RDATE: %s", rdate)
+		}
+		onsets = append(onsets, d)
+	}
+
+	return onsets, nil
+}
+
+// posixRule builds the POSIX TZ string describing the observances past the
+// last computed transition. It is only possible when the timezone is made of
+// either a single fixed observance, or a standard and a daylight observance
+// recurring forever on a given weekday of a given month.
+func posixRule(observances []TimezoneObservance) string {
+	var std, dst *TimezoneObservance
+
+	for idx := range observances {
+		o := &observances[idx]
+
+		if o.RecurrenceRule == "" {
+			if len(observances) == 1 {
+				to, err := ParseUTCOffset(o.OffsetTo)
+				if err != nil {
+					return ""
+				}
+				return posixName(o.Name, to) + posixOffset(to)
+			}
+			continue
+		}
+
+		if _, ok := posixDate(o.RecurrenceRule); !ok {
+			continue
+		}
+
+		if o.Daylight {
+			if dst != nil {
+				return ""
+			}
+			dst = o
+		} else {
+			if std != nil {
+				return ""
+			}
+			std = o
+		}
+	}
+
+	if std == nil || dst == nil {
+		return ""
+	}
+
+	stdOffset, err := ParseUTCOffset(std.OffsetTo)
+	if err != nil {
+		return ""
+	}
+	dstOffset, err := ParseUTCOffset(dst.OffsetTo)
+	if err != nil {
+		return ""
+	}
+
+	dstDate, _ := posixDate(dst.RecurrenceRule)
+	stdDate, _ := posixDate(std.RecurrenceRule)
+
+	dstTime, err := posixTime(dst.Start)
+	if err != nil {
+		return ""
+	}
+	stdTime, err := posixTime(std.Start)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s%s%s%s,%s/%s,%s/%s",
+		posixName(std.Name, stdOffset), posixOffset(stdOffset),
+		posixName(dst.Name, dstOffset), posixOffset(dstOffset),
+		dstDate, dstTime, stdDate, stdTime,
+	)
+}
+
+// posixDate converts an open-ended yearly RRULE such as
+// FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU into its Mm.w.d POSIX form.
+func posixDate(rule string) (string, bool) {
+	_, params := ParseRecurrenceParams(rule)
+
+	for key := range params {
+		switch key {
+		case "FREQ", "BYMONTH", "BYDAY", "WKST":
+		case "INTERVAL":
+			if params[key] != "1" {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+
+	if params["FREQ"] != "YEARLY" {
+		return "", false
+	}
+
+	month, err := strconv.Atoi(params["BYMONTH"])
+	if err != nil || month < 1 || month > 12 {
+		return "", false
+	}
+
+	byday := params["BYDAY"]
+	if len(byday) < 3 {
+		return "", false
+	}
+
+	weekday := strings.Index("SUMOTUWETHFRSA", byday[len(byday)-2:])
+	if weekday < 0 || weekday%2 != 0 {
+		return "", false
+	}
+
+	week, err := strconv.Atoi(byday[:len(byday)-2])
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case week == -1:
+		week = 5
+	case week < 1 || week > 4:
+		return "", false
+	}
+
+	return fmt.Sprintf("M%d.%d.%d", month, week, weekday/2), true
+}
+
+func posixTime(start string) (string, error) {
+	t, err := time.Parse("20060102T150405", start)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second()), nil
+}
+
+func posixName(name string, offset int) string {
+	if name == "" || strings.ContainsAny(name, "<>") {
+		name = formatOffset(offset)
+	}
+
+	return "<" + name + ">"
+}
+
+// posixOffset formats an offset the POSIX way, which is inverted compared to
+// UTC offsets (i.e. UTC+1 is written -1).
+func posixOffset(offset int) string {
+	sign := "-"
+	if offset <= 0 {
+		sign = ""
+		offset = -offset
+	}
+
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, offset/3600, offset%3600/60, offset%60)
+}
+
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	if offset%60 != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, offset/3600, offset%3600/60, offset%60)
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// encodeTZif serializes the zones and transitions into the version 2 TZif
+// format understood by time.LoadLocationFromTZData. The initial zone is always
+// written first, and the legacy 32-bit data block is left empty.
+func encodeTZif(initial zone, zones []zone, transitions []transition, footer string) []byte {
+	var abbrevs bytes.Buffer
+	abbrevIndex := map[string]int{}
+
+	abbrev := func(name string) uint8 {
+		if idx, ok := abbrevIndex[name]; ok {
+			return uint8(idx)
+		}
+		abbrevIndex[name] = abbrevs.Len()
+		abbrevs.WriteString(name)
+		abbrevs.WriteByte(0)
+		return uint8(abbrevIndex[name])
+	}
+
+	var body bytes.Buffer
+	for _, t := range transitions {
+		binary.Write(&body, binary.BigEndian, t.when)
+	}
+	for _, t := range transitions {
+		// Zone 0 is the initial zone, observances' zones are shifted by one
+		body.WriteByte(uint8(t.zone + 1))
+	}
+	for _, z := range append([]zone{initial}, zones...) {
+		binary.Write(&body, binary.BigEndian, int32(z.offset))
+		if z.dst {
+			body.WriteByte(1)
+		} else {
+			body.WriteByte(0)
+		}
+		body.WriteByte(abbrev(z.name))
+	}
+	body.Write(abbrevs.Bytes())
+
+	header := func(b *bytes.Buffer, counts [6]int) {
+		b.WriteString("TZif2")
+		b.Write(make([]byte, 15))
+		for _, c := range counts {
+			binary.Write(b, binary.BigEndian, uint32(c))
+		}
+	}
+
+	var out bytes.Buffer
+	header(&out, [6]int{})
+	header(&out, [6]int{0, 0, 0, len(transitions), len(zones) + 1, abbrevs.Len()})
+	out.Write(body.Bytes())
+	out.WriteString("\n" + footer + "\n")
+
+	return out.Bytes()
+}
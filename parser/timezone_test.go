@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseUTCOffset(t *testing.T) {
+	data := map[string]int{
+		"+0100":   3600,
+		"-0500":   -5 * 3600,
+		"+0530":   5*3600 + 30*60,
+		"-013045": -(3600 + 30*60 + 45),
+	}
+
+	for in, exp := range data {
+		offset, err := ParseUTCOffset(in)
+
+		assert.Nil(t, err)
+		assert.Equal(t, exp, offset)
+	}
+
+	_, err := ParseUTCOffset("0100")
+	assert.NotNil(t, err)
+}
+
+func Test_NewTimezoneLocation(t *testing.T) {
+	loc, err := NewTimezoneLocation("W. Europe Standard Time", []TimezoneObservance{
+		{
+			Name:           "CET",
+			Start:          "16010101T030000",
+			OffsetFrom:     "+0200",
+			OffsetTo:       "+0100",
+			RecurrenceRule: "FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10",
+		},
+		{
+			Daylight:       true,
+			Name:           "CEST",
+			Start:          "16010101T020000",
+			OffsetFrom:     "+0100",
+			OffsetTo:       "+0200",
+			RecurrenceRule: "FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3",
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "W. Europe Standard Time", loc.String())
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	for _, d := range []time.Time{
+		time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 0, 59, 59, 0, time.UTC),
+		time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC),
+		time.Date(2024, 10, 27, 0, 59, 59, 0, time.UTC),
+		time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC),
+		time.Date(2250, 7, 1, 12, 0, 0, 0, time.UTC),
+	} {
+		name, offset := d.In(loc).Zone()
+		_, expected := d.In(paris).Zone()
+
+		assert.Equal(t, expected, offset, d.String())
+		if d.Month() == time.July {
+			assert.Equal(t, "CEST", name)
+		}
+	}
+}
+
+func Test_NewTimezoneLocationFixed(t *testing.T) {
+	loc, err := NewTimezoneLocation("Custom India", []TimezoneObservance{
+		{
+			Start:      "19700101T000000",
+			OffsetFrom: "+0530",
+			OffsetTo:   "+0530",
+		},
+	})
+
+	assert.Nil(t, err)
+
+	_, offset := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone()
+	assert.Equal(t, 5*3600+30*60, offset)
+
+	_, offset = time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone()
+	assert.Equal(t, 5*3600+30*60, offset)
+}
+
+func Test_NewTimezoneLocationHistory(t *testing.T) {
+	// Daylight saving time rules changed in 2007 and DST was abolished in 2030
+	loc, err := NewTimezoneLocation("Custom Eastern", []TimezoneObservance{
+		{
+			Daylight:       true,
+			Start:          "19870405T020000",
+			OffsetFrom:     "-0500",
+			OffsetTo:       "-0400",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=4;BYDAY=1SU;UNTIL=20060402T070000Z",
+		},
+		{
+			Start:          "19671029T020000",
+			OffsetFrom:     "-0400",
+			OffsetTo:       "-0500",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU;UNTIL=20061029T060000Z",
+		},
+		{
+			Daylight:       true,
+			Start:          "20070311T020000",
+			OffsetFrom:     "-0500",
+			OffsetTo:       "-0400",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=3;BYDAY=2SU;UNTIL=20290311T070000Z",
+		},
+		{
+			Start:          "20071104T020000",
+			OffsetFrom:     "-0400",
+			OffsetTo:       "-0500",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=11;BYDAY=1SU;UNTIL=20291104T060000Z",
+		},
+		{
+			Start:      "20300310T020000",
+			OffsetFrom: "-0500",
+			OffsetTo:   "-0500",
+		},
+	})
+
+	assert.Nil(t, err)
+
+	for d, exp := range map[time.Time]int{
+		time.Date(2006, 4, 1, 12, 0, 0, 0, time.UTC):   -5 * 3600,
+		time.Date(2006, 4, 3, 12, 0, 0, 0, time.UTC):   -4 * 3600,
+		time.Date(2006, 10, 30, 12, 0, 0, 0, time.UTC): -5 * 3600,
+		time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC):   -5 * 3600,
+		time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC):  -4 * 3600,
+		time.Date(2024, 11, 4, 12, 0, 0, 0, time.UTC):  -5 * 3600,
+		time.Date(2035, 7, 1, 12, 0, 0, 0, time.UTC):   -5 * 3600,
+	} {
+		_, offset := d.In(loc).Zone()
+		assert.Equal(t, exp, offset, d.String())
+	}
+}
+
+func Test_NewTimezoneLocationDaylightFirst(t *testing.T) {
+	loc, err := NewTimezoneLocation("Custom Central European", []TimezoneObservance{
+		{
+			Daylight:       true,
+			Name:           "CEST",
+			Start:          "19810329T020000",
+			OffsetFrom:     "+0100",
+			OffsetTo:       "+0200",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+		},
+		{
+			Name:           "CET",
+			Start:          "19961027T030000",
+			OffsetFrom:     "+0200",
+			OffsetTo:       "+0100",
+			RecurrenceRule: "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+		},
+	})
+
+	assert.Nil(t, err)
+
+	// Before the first onset, the offset is the one DST starts from
+	name, offset := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone()
+	assert.Equal(t, "CET", name)
+	assert.Equal(t, 3600, offset)
+
+	_, offset = time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone()
+	assert.Equal(t, 2*3600, offset)
+}
@@ -1,40 +1,277 @@
 package gocal
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/teambition/rrule-go"
 )
 
+// recurrence is a recurrence set along with its exclusion rules, which are
+// not supported by rrule-go's Set.
+type recurrence struct {
+	*rrule.Set
+	exrules []*rrule.RRule
+}
+
+// iterator returns a function yielding the occurrences of the set in order,
+// minus the ones generated by the exclusion rules, and false once there are no
+// more. The context is checked for each occurrence, so that pathological rules
+// can be interrupted.
+func (r *recurrence) iterator(ctx context.Context) func() (time.Time, bool, error) {
+	next := r.Set.Iterator()
+
+	exclusions := make([]func() (time.Time, bool), len(r.exrules))
+	heads := make([]*time.Time, len(r.exrules))
+	for idx, x := range r.exrules {
+		exclusions[idx] = x.Iterator()
+	}
+
+	return func() (time.Time, bool, error) {
+		for {
+			if err := ctx.Err(); err != nil {
+				return time.Time{}, false, err
+			}
+
+			occ, ok := next()
+			if !ok {
+				return time.Time{}, false, nil
+			}
+
+			// Exclusion rules are iterated alongside the set, as both are sorted
+			excluded := false
+			for idx, exclusion := range exclusions {
+				for heads[idx] == nil || heads[idx].Before(occ) {
+					d, ok := exclusion()
+					if !ok {
+						break
+					}
+					heads[idx] = &d
+				}
+
+				if heads[idx] != nil && heads[idx].Equal(occ) {
+					excluded = true
+				}
+			}
+
+			if !excluded {
+				return occ, true, nil
+			}
+		}
+	}
+}
+
+// Between returns the occurrences of the set between the given dates, both
+// included, minus the ones generated by the exclusion rules.
+func (r *recurrence) Between(ctx context.Context, after, before time.Time) ([]time.Time, error) {
+	iterate := r.iterator(ctx)
+
+	occs := []time.Time{}
+	for {
+		occ, ok, err := iterate()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || occ.After(before) {
+			break
+		}
+
+		if !occ.Before(after) {
+			occs = append(occs, occ)
+		}
+	}
+
+	return occs, nil
+}
+
+// newRRule parses a recurrence rule anchored on the given start date.
+func newRRule(rule string, start time.Time) (*rrule.RRule, error) {
+	rOption, err := rrule.StrToROptionInLocation(rule, start.Location())
+	if err != nil {
+		return nil, err
+	}
+	rOption.Dtstart = start
+
+	return rrule.NewRRule(*rOption)
+}
+
+// recurrenceSet builds the recurrence set anchored on the given start date,
+// made of the occurrences of an optional RRULE and of explicit dates, minus
+// the excluded dates and the occurrences of the exclusion rules.
+func recurrenceSet(rule string, exrules []string, start time.Time, rdates, exdates []time.Time) (*recurrence, error) {
+	s := rrule.Set{}
+
+	if rule != "" {
+		r, err := newRRule(rule, start)
+		if err != nil {
+			return nil, err
+		}
+
+		s.RRule(r)
+	} else {
+		// Without an RRULE, DTSTART is the first instance of the set
+		s.RDate(start)
+	}
+
+	s.DTStart(start)
+	for _, d := range rdates {
+		s.RDate(d)
+	}
+	s.SetExDates(exdates)
+
+	rec := &recurrence{Set: &s}
+	for _, exrule := range exrules {
+		x, err := newRRule(exrule, start)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.exrules = append(rec.exrules, x)
+	}
+
+	return rec, nil
+}
+
 func (gc *Gocal) ExpandRecurringEvent(buf *Event) ([]Event, error) {
-	rOption, err := rrule.StrToROptionInLocation(buf.RecurrenceRuleString,
-		buf.Start.Location())
+	return expandEvent(gc.context(), buf, *gc.Start, *gc.End)
+}
+
+// expandEvent returns the instances of a recurring event starting within the
+// given dates.
+func expandEvent(ctx context.Context, buf *Event, from, to time.Time) ([]Event, error) {
+	s, periods, err := eventRecurrence(buf)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := rrule.NewRRule(*rOption)
+	evs := []Event{}
+	occs, err := s.Between(ctx, from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	s := rrule.Set{}
-	s.RRule(r)
-	s.DTStart(*buf.Start)
-	s.SetExDates(buf.ExcludeDates)
+	for _, occ := range occs {
+		evs = append(evs, instanceAt(buf, occ, periods))
+	}
 
-	endOffset := buf.End.Sub(*buf.Start)
+	return evs, nil
+}
 
-	evs := []Event{}
-	for _, occ := range s.Between(*gc.Start, *gc.End, true) {
-		start := occ
-		end := start.Add(endOffset)
+// eventRecurrence builds the recurrence set of a recurring event, along with
+// its PERIOD recurrence dates, keyed by their start.
+func eventRecurrence(buf *Event) (*recurrence, map[int64]Period, error) {
+	rdates := append([]time.Time{}, buf.RecurrenceDates...)
+	periods := make(map[int64]Period, len(buf.RecurrencePeriods))
+	for _, p := range buf.RecurrencePeriods {
+		rdates = append(rdates, p.Start)
+		periods[p.Start.Unix()] = p
+	}
 
-		e := *buf
-		e.Start = &start
-		e.End = &end
-		e.Uid = buf.Uid
+	s, err := recurrenceSet(buf.RecurrenceRuleString, buf.ExcludeRuleStrings, *buf.Start, rdates, buf.ExcludeDates)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		evs = append(evs, e)
+	return s, periods, nil
+}
+
+// instanceAt builds the instance of a recurring event starting on one of its
+// occurrences.
+func instanceAt(buf *Event, occ time.Time, periods map[int64]Period) Event {
+	start := occ
+	end := buf.endAt(start)
+
+	// Instances defined by a PERIOD have their own end
+	if p, ok := periods[occ.Unix()]; ok {
+		end = p.End
 	}
 
-	return evs, nil
+	e := *buf
+	e.Start = &start
+	e.End = &end
+	e.RecurrenceID = &occ
+	e.RawRecurrenceID = rawDateAt(buf.RawStart, occ)
+	e.expanded = true
+
+	return e
+}
+
+// rawDateAt formats a date the same way as the given raw one, so that the
+// RECURRENCE-ID of an instance takes the VALUE and TZID of its series' DTSTART.
+func rawDateAt(raw RawDate, t time.Time) RawDate {
+	params := dateParams(raw.Params)
+
+	switch {
+	case params["VALUE"] == "DATE" || len(raw.Value) == 8:
+		return RawDate{Params: params, Value: t.Format("20060102")}
+	case strings.HasSuffix(raw.Value, "Z"):
+		return RawDate{Params: params, Value: t.UTC().Format("20060102T150405Z")}
+	default:
+		return RawDate{Params: params, Value: t.Format("20060102T150405")}
+	}
+}
+
+// isWallClock checks whether the dates of an event are anchored to the wall
+// clock rather than to an instant, as are all-day and floating events.
+func (e *Event) isWallClock() bool {
+	v := e.RawStart
+
+	return v.Params["VALUE"] == "DATE" || len(v.Value) == 8 || (v.Value != "" && v.Params["TZID"] == "" && !strings.HasSuffix(v.Value, "Z"))
+}
+
+// endAt returns the end of the instance of an event starting at the given
+// date. Days and weeks of its DURATION are nominal, as per RFC5545, 3.3.6, and
+// so is the length of all-day and floating events: their instances end at
+// the same wall clock time whatever the DST changes in between.
+func (e *Event) endAt(start time.Time) time.Time {
+	if e.NominalDuration != nil && e.RawEnd.Value == "" {
+		return e.NominalDuration.AddTo(start)
+	}
+	if e.isWallClock() {
+		return addWallClock(start, *e.Start, *e.End)
+	}
+
+	return start.Add(e.End.Sub(*e.Start))
+}
+
+// addWallClock moves a date by the difference between the wall clock times of
+// two others, in its own location.
+func addWallClock(t, from, to time.Time) time.Time {
+	w := wallClock(t).Add(wallClock(to.In(from.Location())).Sub(wallClock(from)))
+
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), t.Location())
+}
+
+// wallClock returns the date with the same wall clock time in UTC, where days
+// always last 24 hours.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func (gc *Gocal) ExpandRecurringJournal(buf *Journal) ([]Journal, error) {
+	s, err := recurrenceSet(buf.RecurrenceRuleString, nil, *buf.Start, nil, buf.ExcludeDates)
+	if err != nil {
+		return nil, err
+	}
+
+	js := []Journal{}
+	occs, err := s.Between(gc.context(), *gc.Start, *gc.End)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, occ := range occs {
+		start, rid := occ, occ
+
+		j := *buf
+		j.Start = &start
+		j.RecurrenceID = &rid
+		j.RawRecurrenceID = rawDateAt(buf.RawStart, rid)
+		j.expanded = true
+
+		js = append(js, j)
+	}
+
+	return js, nil
 }
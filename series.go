@@ -0,0 +1,250 @@
+package gocal
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Series is a recurring event as defined in the feed: its master event, the
+// RECURRENCE-ID overrides replacing some of its instances, or all the
+// following ones for RANGE=THISANDFUTURE overrides, and the dates excluded
+// from it. It is shared by the master event, its instances and its overrides,
+// so that their occurrences can be computed after parsing, whatever the
+// parsing window was. Master is nil when the feed only holds overrides.
+//
+// Events leave their series out of their JSON encoding, so that the master
+// and overrides can be encoded along with the series.
+type Series struct {
+	Master     *Event
+	Overrides  []Event
+	Exceptions []time.Time
+}
+
+// seriesOf returns the series of the given UID, creating it if needed, since
+// overrides may appear before their master event.
+func (gc *Gocal) seriesOf(uid string) *Series {
+	s, ok := gc.Series[uid]
+	if !ok {
+		s = &Series{}
+		gc.Series[uid] = s
+	}
+
+	return s
+}
+
+// isOverridden checks whether the instance starting at the given date is
+// replaced by an override.
+func (s *Series) isOverridden(start time.Time) bool {
+	for _, o := range s.Overrides {
+		if o.RecurrenceID.Equal(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyRangeOverride patches an instance of a recurring event with the latest
+// RANGE=THISANDFUTURE override of its series that starts at or before it. The
+// instance then takes the override's properties, and is shifted by as much as
+// the override was moved from its original start.
+func (s *Series) applyRangeOverride(instance Event) Event {
+	var (
+		override *Event
+		rid      time.Time
+	)
+
+	for idx, o := range s.Overrides {
+		if o.RecurrenceRange != "THISANDFUTURE" || o.RecurrenceID.After(*instance.RecurrenceID) {
+			continue
+		}
+		if override == nil || o.RecurrenceID.After(rid) {
+			override, rid = &s.Overrides[idx], *o.RecurrenceID
+		}
+	}
+
+	if override == nil {
+		return instance
+	}
+
+	start := instance.Start.Add(override.Start.Sub(rid))
+	if override.isWallClock() {
+		start = addWallClock(*instance.Start, rid, *override.Start)
+	}
+	end := override.endAt(start)
+
+	e := *override
+	e.Start = &start
+	e.End = &end
+	e.RecurrenceID = instance.RecurrenceID
+	e.RawRecurrenceID = instance.RawRecurrenceID
+	e.expanded = instance.expanded
+	e.RecurrenceRange = ""
+	e.IsRecurring = instance.IsRecurring
+	e.RecurrenceRule = instance.RecurrenceRule
+	e.RecurrenceRuleString = instance.RecurrenceRuleString
+	e.RecurrenceDates = instance.RecurrenceDates
+	e.RecurrencePeriods = instance.RecurrencePeriods
+	e.ExcludeRules = instance.ExcludeRules
+	e.ExcludeRuleStrings = instance.ExcludeRuleStrings
+	e.ExcludeDates = instance.ExcludeDates
+
+	return e
+}
+
+// rangeShifts returns how far earlier and later RANGE=THISANDFUTURE overrides
+// move instances at most, which bounds how far beyond some dates instances
+// need to be looked for to find all the ones moved within them.
+func (s *Series) rangeShifts() (earlier, later time.Duration) {
+	for _, o := range s.Overrides {
+		if o.RecurrenceRange != "THISANDFUTURE" {
+			continue
+		}
+
+		if shift := o.RecurrenceID.Sub(*o.Start); shift > earlier {
+			earlier = shift
+		} else if -shift > later {
+			later = -shift
+		}
+	}
+
+	return earlier, later
+}
+
+// isShifted checks whether some instances are moved by RANGE=THISANDFUTURE
+// overrides.
+func (s *Series) isShifted() bool {
+	earlier, later := s.rangeShifts()
+
+	return earlier > 0 || later > 0
+}
+
+// instances returns the instances of the master event of the series within the
+// given dates, once overridden ones are removed and RANGE=THISANDFUTURE
+// overrides are applied. Since the latter may move instances, the master event
+// is expanded beyond the dates by as much as they move them.
+func (s *Series) instances(ctx context.Context, from, to time.Time) ([]Event, error) {
+	occs := make([]Event, 0)
+	if s.Master == nil {
+		return occs, nil
+	}
+
+	earlier, later := s.rangeShifts()
+
+	instances, err := expandEvent(ctx, s.Master, from.Add(-later), to.Add(earlier))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range instances {
+		if s.isOverridden(*i.RecurrenceID) {
+			continue
+		}
+
+		if i = s.applyRangeOverride(i); inRange(i, from, to) {
+			occs = append(occs, i)
+		}
+	}
+
+	return occs, nil
+}
+
+// between returns the occurrences of the series within the given dates: the
+// instances of its master event that are not overridden, and its overrides.
+func (s *Series) between(ctx context.Context, from, to time.Time) ([]Event, error) {
+	occs, err := s.instances(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range s.Overrides {
+		if inRange(o, from, to) {
+			occs = append(occs, o)
+		}
+	}
+
+	sort.SliceStable(occs, func(i, j int) bool {
+		return occs[i].Start.Before(*occs[j].Start)
+	})
+
+	return occs, nil
+}
+
+// next returns the first occurrence of the series starting after the given
+// date, or nil if there is none.
+func (s *Series) next(ctx context.Context, after time.Time) (*Event, error) {
+	var next *Event
+
+	for idx, o := range s.Overrides {
+		if o.Start.After(after) && (next == nil || o.Start.Before(*next.Start)) {
+			next = &s.Overrides[idx]
+		}
+	}
+
+	if s.Master != nil {
+		rec, periods, err := eventRecurrence(s.Master)
+		if err != nil {
+			return nil, err
+		}
+
+		shift, _ := s.rangeShifts()
+		iterate := rec.iterator(ctx)
+
+		for {
+			occ, ok, err := iterate()
+			if err != nil {
+				return nil, err
+			}
+			if !ok || (next != nil && occ.After(next.Start.Add(shift))) {
+				break
+			}
+			if s.isOverridden(occ) {
+				continue
+			}
+
+			i := s.applyRangeOverride(instanceAt(s.Master, occ, periods))
+			if i.Start.After(after) && (next == nil || i.Start.Before(*next.Start)) {
+				next = &i
+			}
+		}
+	}
+
+	if next == nil {
+		return nil, nil
+	}
+
+	e := *next
+
+	return &e, nil
+}
+
+// Occurrences returns the occurrences of a recurring event within the given
+// dates, independently of the parsing window: the instances of its series,
+// minus the excluded and overridden ones, along with its RECURRENCE-ID
+// overrides. Events that are not recurring are their only occurrence.
+func (e *Event) Occurrences(from, to time.Time) ([]Event, error) {
+	if e.Series == nil {
+		if inRange(*e, from, to) {
+			return []Event{*e}, nil
+		}
+		return []Event{}, nil
+	}
+
+	return e.Series.between(context.Background(), from, to)
+}
+
+// NextOccurrence returns the first occurrence of a recurring event starting
+// after the given date, or nil when the series is over, so that a series can
+// be paginated through without bounds.
+func (e *Event) NextOccurrence(after time.Time) (*Event, error) {
+	if e.Series == nil {
+		if e.Start.After(after) {
+			next := *e
+			return &next, nil
+		}
+		return nil, nil
+	}
+
+	return e.Series.next(context.Background(), after)
+}
@@ -0,0 +1,220 @@
+package gocal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const seriesICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:series@gocal
+DTSTAMP:20240101T090000Z
+RECURRENCE-ID:20240212T090000Z
+DTSTART:20240213T140000Z
+DTEND:20240213T150000Z
+SUMMARY:Weekly sync (moved)
+END:VEVENT
+BEGIN:VEVENT
+UID:series@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+SUMMARY:Weekly sync
+RRULE:FREQ=WEEKLY;BYDAY=MO
+RDATE:20240131T090000Z
+EXDATE:20240205T090000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:single@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240102T090000Z
+DTEND:20240102T100000Z
+SUMMARY:Single
+END:VEVENT
+END:VCALENDAR`
+
+func parseSeries(t *testing.T) *Gocal {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(seriesICS))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 2)
+
+	return gc
+}
+
+func Test_Series(t *testing.T) {
+	gc := parseSeries(t)
+
+	s := gc.Series["series@gocal"]
+	assert.NotNil(t, s)
+	assert.Equal(t, "Weekly sync", s.Master.Summary)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), *s.Master.Start)
+	assert.Len(t, s.Overrides, 1)
+	assert.Equal(t, "Weekly sync (moved)", s.Overrides[0].Summary)
+	assert.Equal(t, time.Date(2024, 2, 12, 9, 0, 0, 0, time.UTC), *s.Overrides[0].RecurrenceID)
+	assert.Equal(t, []time.Time{time.Date(2024, 2, 5, 9, 0, 0, 0, time.UTC)}, s.Exceptions)
+
+	// Instances reference their series, and carry their original start
+	assert.True(t, s == gc.Events[1].Series)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), *gc.Events[1].RecurrenceID)
+
+	assert.Nil(t, gc.Events[0].Series)
+	assert.NotContains(t, gc.Series, "single@gocal")
+}
+
+func Test_Occurrences(t *testing.T) {
+	gc := parseSeries(t)
+
+	occs, err := gc.Events[1].Occurrences(time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Len(t, occs, 4)
+
+	expected := []time.Time{
+		time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 13, 14, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 19, 9, 0, 0, 0, time.UTC),
+	}
+	for idx, occ := range occs {
+		assert.Equal(t, expected[idx], *occ.Start)
+		assert.Equal(t, "series@gocal", occ.Uid)
+	}
+	assert.Equal(t, "Weekly sync (moved)", occs[2].Summary)
+
+	// Occurrences of events that are not recurring are the events themselves
+	single, err := gc.Events[0].Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Len(t, single, 1)
+	assert.Equal(t, "single@gocal", single[0].Uid)
+}
+
+func Test_NextOccurrence(t *testing.T) {
+	gc := parseSeries(t)
+
+	expected := []time.Time{
+		time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 13, 14, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 19, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 26, 9, 0, 0, 0, time.UTC),
+	}
+
+	after := time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC)
+	for _, exp := range expected {
+		next, err := gc.Events[1].NextOccurrence(after)
+
+		assert.Nil(t, err)
+		assert.Equal(t, exp, *next.Start)
+
+		after = *next.Start
+	}
+
+	next, err := gc.Events[0].NextOccurrence(*gc.Events[0].Start)
+
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+}
+
+func Test_NextOccurrenceEndOfSeries(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:count@gocal
+DTSTAMP:20240101T090000Z
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+END:VCALENDAR`
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+
+	next, err := gc.Events[0].NextOccurrence(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), *next.Start)
+
+	next, err = gc.Events[0].NextOccurrence(*next.Start)
+
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+}
+
+func Test_OccurrencesShifted(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(shiftedRangeICS))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+
+	// Instances shifted earlier from after the dates
+	occs, err := gc.Events[0].Occurrences(time.Date(2024, 1, 23, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Len(t, occs, 1)
+	assert.Equal(t, time.Date(2024, 1, 26, 9, 0, 0, 0, time.UTC), *occs[0].Start)
+	assert.Equal(t, time.Date(2024, 2, 5, 9, 0, 0, 0, time.UTC), *occs[0].RecurrenceID)
+
+	next, err := gc.Events[0].NextOccurrence(time.Date(2024, 1, 23, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Equal(t, *occs[0].Start, *next.Start)
+}
+
+func Test_OccurrencesShiftedLater(t *testing.T) {
+	ics := strings.Replace(shiftedRangeICS, "DTSTART:20240119T090000Z\nDTEND:20240119T100000Z", "DTSTART:20240208T090000Z\nDTEND:20240208T100000Z", 1)
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+
+	// Instances shifted later from before the dates
+	occs, err := gc.Events[0].Occurrences(time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Len(t, occs, 1)
+	assert.Equal(t, time.Date(2024, 2, 15, 9, 0, 0, 0, time.UTC), *occs[0].Start)
+	assert.Equal(t, time.Date(2024, 2, 5, 9, 0, 0, 0, time.UTC), *occs[0].RecurrenceID)
+}
+
+func Test_SeriesJSON(t *testing.T) {
+	gc := parseSeries(t)
+
+	out, err := json.Marshal(gc.Events[1])
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(out), `"Summary":"Weekly sync"`)
+	assert.NotContains(t, string(out), `"Series"`)
+
+	out, err = json.Marshal(gc.Series["series@gocal"])
+
+	assert.Nil(t, err)
+
+	var decoded struct {
+		Master     map[string]interface{}
+		Overrides  []map[string]interface{}
+		Exceptions []time.Time
+	}
+	assert.Nil(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, "series@gocal", decoded.Master["Uid"])
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", decoded.Master["RecurrenceRuleString"])
+	assert.Len(t, decoded.Overrides, 1)
+	assert.Equal(t, "Weekly sync (moved)", decoded.Overrides[0]["Summary"])
+	assert.NotContains(t, decoded.Overrides[0], "Series")
+}
@@ -0,0 +1,48 @@
+package gocal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apognu/gocal/parser"
+)
+
+func (gc *Gocal) parseObservance(l *Line) {
+	switch l.Key {
+	case "DTSTART":
+		gc.obsBuffer.Start = l.Value
+	case "TZOFFSETFROM":
+		gc.obsBuffer.OffsetFrom = l.Value
+	case "TZOFFSETTO":
+		gc.obsBuffer.OffsetTo = l.Value
+	case "TZNAME":
+		gc.obsBuffer.Name = l.Value
+	case "RRULE":
+		gc.obsBuffer.RecurrenceRule = l.Value
+	case "RDATE":
+		gc.obsBuffer.RecurrenceDates = append(gc.obsBuffer.RecurrenceDates, l.values()...)
+	}
+}
+
+// buildTimezone resolves the location of the timezone being parsed. TZIDs that
+// are valid IANA names use Go's timezone database, which holds the complete
+// history of the zone, while others are synthesized from their observances.
+func (gc *Gocal) buildTimezone() error {
+	if gc.tzBuffer.TZID == "" {
+		return fmt.Errorf("could not parse timezone without TZID")
+	}
+
+	if loc, err := time.LoadLocation(gc.tzBuffer.TZID); err == nil {
+		gc.tzBuffer.Location = loc
+		return nil
+	}
+
+	loc, err := parser.NewTimezoneLocation(gc.tzBuffer.TZID, gc.tzBuffer.Observances)
+	if err != nil {
+		return fmt.Errorf("could not parse timezone %s: %w", gc.tzBuffer.TZID, err)
+	}
+
+	gc.tzBuffer.Location = loc
+
+	return nil
+}
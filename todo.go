@@ -0,0 +1,140 @@
+package gocal
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/apognu/gocal/parser"
+)
+
+func (gc *Gocal) parseTodo(l *Line) error {
+	// If this is nil, that means we did not get a BEGIN:VTODO
+	if gc.todoBuffer == nil {
+		return nil
+	}
+
+	switch l.Key {
+	case "UID":
+		if err := resolve(gc, l, &gc.todoBuffer.Uid, resolveString, nil); err != nil {
+			return err
+		}
+	case "SUMMARY":
+		if err := resolve(gc, l, &gc.todoBuffer.Summary, resolveString, nil); err != nil {
+			return err
+		}
+	case "DESCRIPTION":
+		if err := resolve(gc, l, &gc.todoBuffer.Description, resolveString, nil); err != nil {
+			return err
+		}
+	case "DTSTART":
+		if err := resolve(gc, l, &gc.todoBuffer.Start, resolveDate, func(gc *Gocal, out *time.Time) {
+			gc.todoBuffer.RawStart = RawDate{Value: l.Value, Params: l.Params}
+		}); err != nil {
+			return err
+		}
+	case "DUE":
+		if err := resolve(gc, l, &gc.todoBuffer.Due, resolveDate, func(gc *Gocal, out *time.Time) {
+			gc.todoBuffer.RawDue = RawDate{Value: l.Value, Params: l.Params}
+		}); err != nil {
+			return err
+		}
+	case "DURATION":
+		// The DURATION attribute should imply DUE as DTSTART+DURATION.
+		// If we have not processed DTSTART yet, add this to the delayed attributes to be processed later.
+		if gc.todoBuffer.Start == nil {
+			gc.todoBuffer.delayed = append(gc.todoBuffer.delayed, l)
+			return nil
+		}
+
+		if err := resolve(gc, l, &gc.todoBuffer.NominalDuration, resolveNominalDuration, func(gc *Gocal, out *parser.NominalDuration) {
+			if out != nil {
+				d, due := out.Duration(), out.AddTo(*gc.todoBuffer.Start)
+				gc.todoBuffer.Duration = &d
+				gc.todoBuffer.Due = &due
+			}
+		}); err != nil {
+			return err
+		}
+	case "COMPLETED":
+		if err := resolve(gc, l, &gc.todoBuffer.Completed, resolveDate, nil); err != nil {
+			return err
+		}
+	case "PERCENT-COMPLETE":
+		if err := resolve(gc, l, &gc.todoBuffer.PercentComplete, resolveInt, nil); err != nil {
+			return err
+		}
+	case "PRIORITY":
+		if err := resolve(gc, l, &gc.todoBuffer.Priority, resolveInt, nil); err != nil {
+			return err
+		}
+	case "DTSTAMP":
+		if err := resolve(gc, l, &gc.todoBuffer.Stamp, resolveDate, nil); err != nil {
+			return err
+		}
+	case "CREATED":
+		if err := resolve(gc, l, &gc.todoBuffer.Created, resolveDate, nil); err != nil {
+			return err
+		}
+	case "LAST-MODIFIED":
+		if err := resolve(gc, l, &gc.todoBuffer.LastModified, resolveDate, nil); err != nil {
+			return err
+		}
+	case "SEQUENCE":
+		gc.todoBuffer.Sequence, _ = strconv.Atoi(l.Value)
+	case "LOCATION":
+		if err := resolve(gc, l, &gc.todoBuffer.Location, resolveString, nil); err != nil {
+			return err
+		}
+	case "STATUS":
+		if err := resolve(gc, l, &gc.todoBuffer.Status, resolveString, nil); err != nil {
+			return err
+		}
+	case "ORGANIZER":
+		if err := resolve(gc, l, &gc.todoBuffer.Organizer, resolveOrganizer, nil); err != nil {
+			return err
+		}
+	case "ATTENDEE":
+		gc.todoBuffer.Attendees = append(gc.todoBuffer.Attendees, parseAttendee(l))
+	case "ATTACH":
+		gc.todoBuffer.Attachments = append(gc.todoBuffer.Attachments, parseAttachment(l))
+	case "GEO":
+		if err := resolve(gc, l, &gc.todoBuffer.Geo, resolveGeo, nil); err != nil {
+			return err
+		}
+	case "CATEGORIES":
+		gc.todoBuffer.Categories = append(gc.todoBuffer.Categories, l.values()...)
+	case "RESOURCES":
+		gc.todoBuffer.Resources = append(gc.todoBuffer.Resources, l.values()...)
+	case "URL":
+		gc.todoBuffer.URL = l.Value
+	case "COMMENT":
+		gc.todoBuffer.Comment = l.Value
+	case "CLASS":
+		gc.todoBuffer.Class = l.Value
+	default:
+		parseCustomAttribute(l, &gc.todoBuffer.CustomAttributes)
+	}
+
+	return nil
+}
+
+func (gc *Gocal) checkTodo() error {
+	if gc.todoBuffer.Uid == "" {
+		gc.todoBuffer.Valid = false
+		return fmt.Errorf("could not parse todo without UID")
+	}
+	if gc.todoBuffer.Stamp == nil {
+		gc.todoBuffer.Valid = false
+		return fmt.Errorf("could not parse todo without DTSTAMP")
+	}
+	if gc.todoBuffer.RawDue.Value != "" && gc.todoBuffer.Duration != nil {
+		return fmt.Errorf("only one of DUE and DURATION must be provided")
+	}
+	if gc.todoBuffer.Duration == nil && len(gc.todoBuffer.delayed) > 0 {
+		gc.todoBuffer.Valid = false
+		return fmt.Errorf("could not parse todo with DURATION but without DTSTART")
+	}
+
+	return nil
+}
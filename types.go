@@ -2,6 +2,7 @@ package gocal
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -41,23 +42,126 @@ func (err DuplicateAttributeError) Error() string {
 	return fmt.Sprintf("duplicate attribute %s: %s", err.Key, err.Value)
 }
 
+// ParseError is returned when parsing a feed fails, and locates the content
+// line that caused the failure. Errors detected when a component ends, such as
+// missing required properties, are located on its END line.
+type ParseError struct {
+	// Line is the number of the physical line the content line starts on. It
+	// is zero for jCal and xCal documents, or when no line is involved.
+	Line int
+	// Text is the unfolded content line.
+	Text string
+	Key  string
+	// UID is the UID of the component being parsed, if known.
+	UID string
+	Err error
+}
+
+func newParseError(l *Line, uid string, err error) *ParseError {
+	return &ParseError{Line: l.number, Text: l.text, Key: l.Key, UID: uid, Err: err}
+}
+
+func (err *ParseError) Error() string {
+	location := []string{}
+	if err.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", err.Line))
+	}
+	if err.Key != "" {
+		location = append(location, err.Key)
+	}
+	if err.UID != "" {
+		location = append(location, fmt.Sprintf("UID %s", err.UID))
+	}
+
+	if len(location) == 0 {
+		return fmt.Sprintf("gocal error: %s", err.Err)
+	}
+
+	return fmt.Sprintf("gocal error: %s: %s", strings.Join(location, ", "), err.Err)
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// WarningKind is the kind of issue reported by a Warning.
+type WarningKind int
+
+const (
+	// A component was skipped, along with its data
+	WarningSkippedComponent WarningKind = iota
+	// An attribute could not be parsed and was dropped, or a required one is
+	// missing from a component that was kept anyway
+	WarningDroppedAttribute
+	// A TZID could not be resolved, and its dates were considered UTC. It is
+	// also reported when a VTIMEZONE defines such a TZID after its dates
+	WarningTimezoneFallback
+	// A content line could not be parsed and was ignored
+	WarningUnparseableLine
+)
+
+// Warning is a non-fatal issue met while parsing a feed, for which data was
+// dropped or guessed. It is located the same way as a ParseError.
+type Warning struct {
+	Kind WarningKind
+	*ParseError
+}
+
+var errInvalidComponent = fmt.Errorf("component has invalid attributes")
+
+func (gc *Gocal) warn(kind WarningKind, l *Line, uid string, err error) {
+	gc.Warnings = append(gc.Warnings, Warning{Kind: kind, ParseError: newParseError(l, uid, err)})
+}
+
 type Gocal struct {
 	scanner        *bufio.Scanner
+	unfolder       *unfolder
+	line           *Line
+	fallbackTZIDs  map[string]bool
+	ctx            context.Context
+	decode         func() ([]*Line, error)
+	lines          []*Line
+	stack          *Context
+	eof            bool
+	instances      []Event
+	jInstances     []Journal
+	pending        []Event
 	Events         []Event
+	Todos          []Todo
+	Timezones      map[string]*Timezone
+	Journals       []Journal
 	SkipBounds     bool
+	MaxLineSize    int
 	Strict         StrictParams
 	Duplicate      DuplicateParams
 	buffer         *Event
+	todoBuffer     *Todo
+	journalBuffer  *Journal
+	alarmBuffer    *Alarm
+	tzBuffer       *Timezone
+	obsBuffer      *parser.TimezoneObservance
+	Series         map[string]*Series
+	jOverrides     map[string][]Journal
 	Start          *time.Time
 	End            *time.Time
 	Method         string
+	Warnings       []Warning
 	AllDayEventsTZ *time.Location
+	TZResolver     parser.TZResolver
+	// WindowsZones enables the mapping of Windows timezone names to IANA ones,
+	// when TZIDs cannot be resolved otherwise
+	WindowsZones bool
 }
 
 const (
 	ContextRoot = iota
 	ContextEvent
 	ContextUnknown
+	ContextTodo
+	ContextJournal
+	ContextAlarm
+	ContextTimezone
+	ContextObservance
 )
 
 type Context struct {
@@ -70,21 +174,67 @@ func (ctx *Context) Nest(value int) *Context {
 }
 
 func (gc *Gocal) IsInRange(d Event) bool {
-	if (d.Start.Before(*gc.Start) && d.End.After(*gc.Start)) ||
-		(d.Start.After(*gc.Start) && d.End.Before(*gc.End)) ||
-		(d.Start.Before(*gc.End) && d.End.After(*gc.End)) {
+	return inRange(d, *gc.Start, *gc.End)
+}
+
+func inRange(d Event, start, end time.Time) bool {
+	if (d.Start.Before(start) && d.End.After(start)) ||
+		(d.Start.After(start) && d.End.Before(end)) ||
+		(d.Start.Before(end) && d.End.After(end)) {
 		return true
 	}
 	return false
 }
 
+// IsTodoInRange checks whether a todo falls within the parsing window. Todos
+// are matched on their DTSTART and DUE dates, whichever are set, and todos
+// carrying neither are always considered in range.
+func (gc *Gocal) IsTodoInRange(t Todo) bool {
+	switch {
+	case t.Start != nil && t.Due != nil:
+		return !t.Start.After(*gc.End) && !t.Due.Before(*gc.Start)
+	case t.Due != nil:
+		return !t.Due.Before(*gc.Start) && !t.Due.After(*gc.End)
+	case t.Start != nil:
+		return !t.Start.Before(*gc.Start) && !t.Start.After(*gc.End)
+	}
+	return true
+}
+
+// IsJournalInRange checks whether a journal entry's DTSTART falls within the
+// parsing window. Journal entries without a DTSTART are always considered in range.
+func (gc *Gocal) IsJournalInRange(j Journal) bool {
+	if j.Start == nil {
+		return true
+	}
+	return !j.Start.Before(*gc.Start) && !j.Start.After(*gc.End)
+}
+
+// IsRecurringInstanceOverriden checks whether an instance of a recurring event
+// is replaced by a RECURRENCE-ID override. All the overrides found in the feed
+// are considered, even those that were moved out of the parsing window.
 func (gc *Gocal) IsRecurringInstanceOverriden(instance *Event) bool {
-	for _, e := range gc.Events {
-		if e.Uid == instance.Uid {
-			rid, _ := parser.ParseTime(e.RecurrenceID, e.RawStart.Params, parser.TimeStart, false, gc.AllDayEventsTZ)
-			if rid.Equal(*instance.Start) {
-				return true
-			}
+	s, ok := gc.Series[instance.Uid]
+
+	if !ok {
+		return false
+	}
+	if instance.RecurrenceID != nil {
+		return s.isOverridden(*instance.RecurrenceID)
+	}
+
+	return s.isOverridden(*instance.Start)
+}
+
+func (gc *Gocal) IsRecurringJournalOverriden(instance *Journal) bool {
+	rid := instance.Start
+	if instance.RecurrenceID != nil {
+		rid = instance.RecurrenceID
+	}
+
+	for _, j := range gc.jOverrides[instance.Uid] {
+		if j.RecurrenceID.Equal(*rid) {
+			return true
 		}
 	}
 	return false
@@ -94,6 +244,9 @@ type Line struct {
 	Key    string
 	Params map[string]string
 	Value  string
+	number int
+	text   string
+	raw    string
 }
 
 func (l *Line) Is(key, value string) bool {
@@ -111,6 +264,17 @@ func (l *Line) IsValue(value string) bool {
 	return strings.TrimSpace(l.Value) == value
 }
 
+// values splits the value of a multi-valued property on the commas that are
+// not escaped, unescaping each value.
+func (l *Line) values() []string {
+	values := splitEscaped(l.raw)
+	for idx, v := range values {
+		values[idx] = parser.UnescapeString(v)
+	}
+
+	return values
+}
+
 type RawDate struct {
 	Params map[string]string
 	Value  string
@@ -118,16 +282,24 @@ type RawDate struct {
 
 type Event struct {
 	delayed []*Line
+	// expanded is set on the instances generated from recurrence rules and
+	// dates, as opposed to the overrides found in the feed
+	expanded bool
+	// skipped is set once an event is skipped for one of its alarms, so that
+	// it is only reported once
+	skipped bool
 
 	Uid                  string
 	Summary              string
 	Description          string
 	Categories           []string
+	Resources            []string
 	Start                *time.Time
 	RawStart             RawDate
 	End                  *time.Time
 	RawEnd               RawDate
 	Duration             *time.Duration
+	NominalDuration      *parser.NominalDuration
 	Stamp                *time.Time
 	Created              *time.Time
 	LastModified         *time.Time
@@ -139,15 +311,126 @@ type Event struct {
 	Attendees            []Attendee
 	Attachments          []Attachment
 	IsRecurring          bool
-	RecurrenceID         string
-	RecurrenceRule       map[string]string
+	Series               *Series `json:"-"`
+	RecurrenceID         *time.Time
+	RawRecurrenceID      RawDate
+	RecurrenceRange      string
+	RecurrenceRule       *parser.RRule
 	RecurrenceRuleString string
+	RecurrenceDates      []time.Time
+	RecurrencePeriods    []Period
+	ExcludeRules         []*parser.RRule
+	ExcludeRuleStrings   []string
 	ExcludeDates         []time.Time
 	Sequence             int
 	CustomAttributes     map[string]string
 	Valid                bool
 	Comment              string
 	Class                string
+	Alarms               []Alarm
+}
+
+type Todo struct {
+	delayed []*Line
+
+	Uid              string
+	Summary          string
+	Description      string
+	Categories       []string
+	Resources        []string
+	Start            *time.Time
+	RawStart         RawDate
+	Due              *time.Time
+	RawDue           RawDate
+	Duration         *time.Duration
+	NominalDuration  *parser.NominalDuration
+	Completed        *time.Time
+	Stamp            *time.Time
+	Created          *time.Time
+	LastModified     *time.Time
+	Location         string
+	Geo              *Geo
+	URL              string
+	Status           string
+	PercentComplete  int
+	Priority         int
+	Organizer        *Organizer
+	Attendees        []Attendee
+	Attachments      []Attachment
+	Sequence         int
+	CustomAttributes map[string]string
+	Valid            bool
+	Comment          string
+	Class            string
+}
+
+type Journal struct {
+	expanded bool
+
+	Uid                  string
+	Summary              string
+	Descriptions         []string
+	Categories           []string
+	Start                *time.Time
+	RawStart             RawDate
+	Stamp                *time.Time
+	Created              *time.Time
+	LastModified         *time.Time
+	URL                  string
+	Status               string
+	Organizer            *Organizer
+	Attendees            []Attendee
+	Attachments          []Attachment
+	IsRecurring          bool
+	RecurrenceID         *time.Time
+	RawRecurrenceID      RawDate
+	RecurrenceRule       *parser.RRule
+	RecurrenceRuleString string
+	ExcludeDates         []time.Time
+	Sequence             int
+	CustomAttributes     map[string]string
+	Valid                bool
+	Comment              string
+	Class                string
+}
+
+type Alarm struct {
+	Action           string
+	Trigger          Trigger
+	Repeat           int
+	Duration         *time.Duration
+	Summary          string
+	Description      string
+	Attendees        []Attendee
+	Attachments      []Attachment
+	CustomAttributes map[string]string
+	Valid            bool
+}
+
+// Trigger holds when an alarm should fire. It is either a duration relative to
+// the start or the end of its event, as indicated by Related, or an absolute time.
+// Days and weeks of the duration are nominal, and are kept as such in
+// NominalDuration.
+type Trigger struct {
+	Duration        *time.Duration
+	NominalDuration *parser.NominalDuration
+	Related         string
+	Time            *time.Time
+}
+
+// Timezone is a VTIMEZONE component defined in the feed, along with the
+// location synthesized from its observances. It only applies to the
+// components following it in the feed, as dates are resolved while parsing.
+type Timezone struct {
+	TZID        string
+	Observances []parser.TimezoneObservance
+	Location    *time.Location
+}
+
+// Period is a PERIOD value, such as the ones found in RDATE properties.
+type Period struct {
+	Start time.Time
+	End   time.Time
 }
 
 type Geo struct {
@@ -0,0 +1,93 @@
+package gocal
+
+import (
+	"bytes"
+)
+
+// DefaultMaxLineSize is the maximum size of an unfolded content line when
+// Gocal.MaxLineSize is not set. Lines can get large when they hold inline
+// binary attachments.
+const DefaultMaxLineSize = 16 * 1024 * 1024
+
+// unfolder splits a feed into unfolded content lines, as per RFC5545, 3.1, so
+// that a bufio.Scanner only buffers the line it is reading, whose size is then
+// bounded by the scanner's maximum token size. Lines can end with CRLF or LF,
+// and are continued by lines starting with a space or a tab, which may split
+// multibyte characters since unfolding works on bytes. It keeps track of the
+// physical line numbers content lines start on.
+//
+// The scanner calls split again with more data until a whole content line is
+// read, so the unfolded part of the line is kept between calls, in order not
+// to read it again.
+type unfolder struct {
+	// Number of physical lines consumed so far
+	lines int
+	// Physical line number the last content line started on
+	start int
+
+	// Content line being read, and the number of physical lines it spans
+	line  []byte
+	count int
+	// Offset in the scanner's data of the next physical line to read, and of
+	// the first byte that was not searched for a line ending
+	offset   int
+	searched int
+	// Whether a physical line ended at offset, which is only known to be
+	// continued once the next one is read
+	ended bool
+}
+
+func (u *unfolder) split(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	for {
+		if u.ended {
+			if u.offset == len(data) && !atEOF {
+				return 0, nil, nil
+			}
+			if u.offset == len(data) || (data[u.offset] != ' ' && data[u.offset] != '\t') {
+				return u.token(u.offset)
+			}
+
+			u.ended = false
+			u.offset++
+		}
+
+		from := max(u.offset, u.searched)
+		idx := bytes.IndexByte(data[from:], '\n')
+		if idx < 0 {
+			if !atEOF {
+				u.searched = len(data)
+				return 0, nil, nil
+			}
+
+			u.line = append(u.line, dropCR(data[u.offset:])...)
+			u.count++
+
+			return u.token(len(data))
+		}
+
+		end := from + idx
+		u.line = append(u.line, dropCR(data[u.offset:end])...)
+		u.count++
+		u.offset, u.ended = end+1, true
+	}
+}
+
+// token returns the content line that was read, and resets the state of the
+// unfolder for the next one.
+func (u *unfolder) token(advance int) (int, []byte, error) {
+	line := u.line
+
+	u.start = u.lines + 1
+	u.lines += u.count
+	u.line, u.count, u.offset, u.searched, u.ended = nil, 0, 0, 0, false
+
+	return advance, line, nil
+}
+
+func dropCR(b []byte) []byte {
+	return bytes.TrimSuffix(b, []byte{'\r'})
+}
@@ -0,0 +1,122 @@
+package gocal
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/apognu/gocal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func unfoldAll(s string) []string {
+	return unfoldReader(strings.NewReader(s))
+}
+
+func unfoldReader(r io.Reader) []string {
+	u := &unfolder{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), DefaultMaxLineSize)
+	scanner.Split(u.split)
+
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
+
+func Test_Unfold(t *testing.T) {
+	lines := unfoldAll("DESCRIPTION:Lorem\r\n  ipsum\r\n\tdolor\nSUMMARY:Caf\xc3\r\n \xa9\nLOCATION:Paris")
+
+	assert.Equal(t, []string{"DESCRIPTION:Lorem ipsumdolor", "SUMMARY:Café", "LOCATION:Paris"}, lines)
+}
+
+// Test_UnfoldSmallReads reads a long folded line one byte at a time, which
+// would take minutes if it were unfolded again on every read.
+func Test_UnfoldSmallReads(t *testing.T) {
+	value := strings.Repeat("A", 2*1024*1024)
+
+	var folded strings.Builder
+	folded.WriteString("ATTACH:")
+	for idx := 0; idx < len(value); idx += 74 {
+		folded.WriteString(value[idx:min(idx+74, len(value))])
+		folded.WriteString("\r\n ")
+	}
+	folded.WriteString("\r\nEND:VEVENT")
+
+	lines := unfoldReader(iotest.OneByteReader(strings.NewReader(folded.String())))
+
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "ATTACH:"+value, lines[0])
+	assert.Equal(t, "END:VEVENT", lines[1])
+}
+
+func Test_UnfoldDescription(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTAMP:20240101T000000Z\r\nUID:unfold@gocal\r\n" +
+		"DTSTART:20240105T090000Z\r\nDTEND:20240105T100000Z\r\n" +
+		"DESCRIPTION:First line\\nSecond line\\, with a comma\\NThird line \r\n\twith a tab\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewParser(strings.NewReader(ics))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, "First line\nSecond line, with a comma\nThird line with a tab", gc.Events[0].Description)
+}
+
+// Fuzz_Unfold folds a content line at arbitrary bytes, with arbitrary line
+// endings and continuation characters, and checks it is unfolded as it was.
+func Fuzz_Unfold(f *testing.F) {
+	f.Add("DESCRIPTION:Lorem ipsum dolor sit amet", uint8(5), uint8(0))
+	f.Add("SUMMARY:Café crème 🥐", uint8(1), uint8(3))
+	f.Add("X-EMPTY:", uint8(75), uint8(2))
+	f.Add("", uint8(0), uint8(1))
+
+	separators := []string{"\r\n ", "\n ", "\r\n\t", "\n\t"}
+
+	f.Fuzz(func(t *testing.T, line string, width uint8, seed uint8) {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Skip()
+		}
+
+		var folded strings.Builder
+		rest := line
+		for idx := 0; width > 0 && len(rest) > int(width); idx++ {
+			folded.WriteString(rest[:width])
+			folded.WriteString(separators[(int(seed)+idx)%len(separators)])
+			rest = rest[width:]
+		}
+		folded.WriteString(rest)
+		folded.WriteString(separators[int(seed)%2][:len(separators[int(seed)%2])-1])
+		folded.WriteString("END:VEVENT")
+
+		lines := unfoldAll(folded.String())
+		assert.Equal(t, lines, unfoldReader(iotest.OneByteReader(strings.NewReader(folded.String()))))
+
+		if line == "" {
+			assert.Equal(t, []string{"END:VEVENT"}, lines)
+			return
+		}
+
+		assert.Equal(t, []string{line, "END:VEVENT"}, lines)
+	})
+}
+
+func Fuzz_UnescapeString(f *testing.F) {
+	f.Add("Comma, semicolon; backslash \\ and\nnewline")
+	f.Add("\\n")
+
+	f.Add("\r\r\n")
+
+	// CRLF line breaks are encoded the same as LF ones
+	f.Fuzz(func(t *testing.T, text string) {
+		assert.Equal(t, strings.ReplaceAll(text, "\r\n", "\n"), parser.UnescapeString(escapeText(text)))
+	})
+}
@@ -0,0 +1,296 @@
+package gocal
+
+import (
+	"strings"
+
+	"github.com/apognu/gocal/parser"
+)
+
+// Value types of the properties when not specified by a VALUE parameter, as
+// per RFC5545, 3.8. Other properties are of the "unknown" type of jCal and
+// xCal, whose values are kept in their iCalendar syntax.
+var propertyTypes = map[string]string{
+	"ACTION":           "text",
+	"ATTACH":           "uri",
+	"ATTENDEE":         "cal-address",
+	"CALSCALE":         "text",
+	"CATEGORIES":       "text",
+	"CLASS":            "text",
+	"COMMENT":          "text",
+	"COMPLETED":        "date-time",
+	"CONTACT":          "text",
+	"CREATED":          "date-time",
+	"DESCRIPTION":      "text",
+	"DTEND":            "date-time",
+	"DTSTAMP":          "date-time",
+	"DTSTART":          "date-time",
+	"DUE":              "date-time",
+	"DURATION":         "duration",
+	"EXDATE":           "date-time",
+	"EXRULE":           "recur",
+	"GEO":              "float",
+	"LAST-MODIFIED":    "date-time",
+	"LOCATION":         "text",
+	"METHOD":           "text",
+	"ORGANIZER":        "cal-address",
+	"PERCENT-COMPLETE": "integer",
+	"PRIORITY":         "integer",
+	"PRODID":           "text",
+	"RDATE":            "date-time",
+	"RECURRENCE-ID":    "date-time",
+	"RELATED-TO":       "text",
+	"REPEAT":           "integer",
+	"RESOURCES":        "text",
+	"RRULE":            "recur",
+	"SEQUENCE":         "integer",
+	"STATUS":           "text",
+	"SUMMARY":          "text",
+	"TRANSP":           "text",
+	"TRIGGER":          "duration",
+	"TZID":             "text",
+	"TZNAME":           "text",
+	"TZOFFSETFROM":     "utc-offset",
+	"TZOFFSETTO":       "utc-offset",
+	"TZURL":            "uri",
+	"UID":              "text",
+	"URL":              "uri",
+	"VERSION":          "text",
+}
+
+// Properties holding comma-separated lists of values.
+var multiValueProperties = map[string]bool{
+	"CATEGORIES": true,
+	"EXDATE":     true,
+	"RDATE":      true,
+	"RESOURCES":  true,
+}
+
+// Parts of recurrence rules holding numbers.
+var numericRecurParts = map[string]bool{
+	"count":      true,
+	"interval":   true,
+	"bysecond":   true,
+	"byminute":   true,
+	"byhour":     true,
+	"bymonthday": true,
+	"byyearday":  true,
+	"byweekno":   true,
+	"bymonth":    true,
+	"bysetpos":   true,
+}
+
+// property is a content line converted from its iCalendar syntax to the one
+// shared by jCal and xCal: names are lowercase, the VALUE parameter is turned
+// into a type, dates and offsets use their extended ISO 8601 format, and text
+// values are unescaped and split.
+type property struct {
+	Name   string
+	Params map[string]string
+	Type   string
+	Values []string
+	Recur  []recurPart
+}
+
+type recurPart struct {
+	Name   string
+	Values []string
+}
+
+func propertyType(key string) string {
+	if ty, ok := propertyTypes[key]; ok {
+		return ty
+	}
+
+	return "unknown"
+}
+
+func toProperty(l *Line) property {
+	p := property{
+		Name:   strings.ToLower(l.Key),
+		Params: make(map[string]string),
+		Type:   propertyType(l.Key),
+	}
+
+	for key, val := range l.Params {
+		if key == "VALUE" {
+			p.Type = strings.ToLower(val)
+			continue
+		}
+		p.Params[strings.ToLower(key)] = strings.Trim(val, `"`)
+	}
+
+	switch {
+	case p.Type == "recur":
+		for _, part := range strings.Split(l.Value, ";") {
+			tokens := strings.SplitN(part, "=", 2)
+			if len(tokens) != 2 {
+				continue
+			}
+
+			name := strings.ToLower(tokens[0])
+			values := strings.Split(tokens[1], ",")
+			if name == "until" {
+				values[0] = toExtendedDate(values[0])
+			}
+
+			p.Recur = append(p.Recur, recurPart{Name: name, Values: values})
+		}
+	case p.Name == "geo":
+		p.Values = strings.SplitN(l.Value, ";", 2)
+	case p.Type == "text":
+		values := []string{l.Value}
+		if multiValueProperties[l.Key] {
+			values = splitEscaped(l.Value)
+		}
+		for _, v := range values {
+			p.Values = append(p.Values, parser.UnescapeString(v))
+		}
+	case multiValueProperties[l.Key]:
+		for _, v := range strings.Split(l.Value, ",") {
+			p.Values = append(p.Values, toExtended(p.Type, v))
+		}
+	default:
+		p.Values = []string{toExtended(p.Type, l.Value)}
+	}
+
+	return p
+}
+
+func fromProperty(p property) *Line {
+	l := &Line{Key: strings.ToUpper(p.Name), Params: make(map[string]string)}
+
+	for key, val := range p.Params {
+		l.Params[strings.ToUpper(key)] = encodeParam(val)
+	}
+	if p.Type != propertyType(l.Key) && p.Type != "unknown" {
+		l.Params["VALUE"] = strings.ToUpper(p.Type)
+	}
+
+	switch {
+	case p.Type == "recur":
+		parts := make([]string, len(p.Recur))
+		for idx, part := range p.Recur {
+			values := append([]string{}, part.Values...)
+			if part.Name == "until" && len(values) > 0 {
+				values[0] = fromExtendedDate(values[0])
+			}
+			parts[idx] = strings.ToUpper(part.Name) + "=" + strings.Join(values, ",")
+		}
+		l.Value = strings.Join(parts, ";")
+	case l.Key == "GEO":
+		l.Value = strings.Join(p.Values, ";")
+	case p.Type == "text":
+		values := make([]string, len(p.Values))
+		for idx, v := range p.Values {
+			values[idx] = escapeText(v)
+		}
+		l.Value = strings.Join(values, ",")
+	default:
+		values := make([]string, len(p.Values))
+		for idx, v := range p.Values {
+			values[idx] = fromExtended(p.Type, v)
+		}
+		l.Value = strings.Join(values, ",")
+	}
+
+	return l
+}
+
+// splitEscaped splits a list of text values on the commas that are not escaped.
+func splitEscaped(s string) []string {
+	var (
+		values  []string
+		escaped bool
+		start   int
+	)
+
+	for idx := 0; idx < len(s); idx++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[idx] == '\\':
+			escaped = true
+		case s[idx] == ',':
+			values = append(values, s[start:idx])
+			start = idx + 1
+		}
+	}
+
+	return append(values, s[start:])
+}
+
+// toExtended converts a value from its basic iCalendar format to the extended
+// one used by jCal and xCal (e.g. 20240101T090000Z to 2024-01-01T09:00:00Z).
+func toExtended(ty, v string) string {
+	switch ty {
+	case "date", "date-time":
+		return toExtendedDate(v)
+	case "time":
+		return toExtendedTime(v)
+	case "utc-offset":
+		if len(v) < 5 {
+			return v
+		}
+		return v[:3] + ":" + toExtendedTime(v[3:])
+	case "period":
+		tokens := strings.SplitN(v, "/", 2)
+		for idx, token := range tokens {
+			if !isDuration(token) {
+				tokens[idx] = toExtendedDate(token)
+			}
+		}
+		return strings.Join(tokens, "/")
+	}
+
+	return v
+}
+
+func fromExtended(ty, v string) string {
+	switch ty {
+	case "date", "date-time":
+		return fromExtendedDate(v)
+	case "time", "utc-offset":
+		return strings.ReplaceAll(v, ":", "")
+	case "period":
+		tokens := strings.SplitN(v, "/", 2)
+		for idx, token := range tokens {
+			if !isDuration(token) {
+				tokens[idx] = fromExtendedDate(token)
+			}
+		}
+		return strings.Join(tokens, "/")
+	}
+
+	return v
+}
+
+func toExtendedDate(v string) string {
+	if len(v) < 8 {
+		return v
+	}
+
+	date := v[:4] + "-" + v[4:6] + "-" + v[6:8]
+	if len(v) > 9 && v[8] == 'T' {
+		return date + "T" + toExtendedTime(v[9:])
+	}
+
+	return date
+}
+
+func toExtendedTime(v string) string {
+	var parts []string
+	for len(v) >= 2 && v[0] >= '0' && v[0] <= '9' {
+		parts = append(parts, v[:2])
+		v = v[2:]
+	}
+
+	return strings.Join(parts, ":") + v
+}
+
+func fromExtendedDate(v string) string {
+	return strings.NewReplacer("-", "", ":", "").Replace(v)
+}
+
+func isDuration(v string) bool {
+	return strings.HasPrefix(strings.TrimLeft(v, "+-"), "P")
+}
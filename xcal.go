@@ -0,0 +1,335 @@
+package gocal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xcalNamespace = "urn:ietf:params:xml:ns:icalendar-2.0"
+
+// Value types of parameters in xCal, as per RFC6321, 3.5. Others are text.
+var xcalParamTypes = map[string]string{
+	"altrep":         "uri",
+	"delegated-from": "cal-address",
+	"delegated-to":   "cal-address",
+	"dir":            "uri",
+	"member":         "cal-address",
+	"sent-by":        "cal-address",
+}
+
+// XCalEncoder writes calendars in the xCal format, as per RFC6321.
+type XCalEncoder struct {
+	w      io.Writer
+	ProdID string
+}
+
+func NewXCalEncoder(w io.Writer) *XCalEncoder {
+	return &XCalEncoder{w: w, ProdID: DefaultProdID}
+}
+
+// Encode writes a whole calendar, the same way Encoder.Encode does.
+func (enc *XCalEncoder) Encode(gc *Gocal) error {
+	return enc.write(calendarComponent(gc, enc.ProdID))
+}
+
+// EncodeEvent writes a single vevent component, wrapped in a vcalendar one
+// since RFC6321 documents hold calendars only.
+func (enc *XCalEncoder) EncodeEvent(e Event) error {
+	c := emptyCalendar(enc.ProdID)
+	c.Components = append(c.Components, eventComponent(e, false))
+
+	return enc.write(c)
+}
+
+func (enc *XCalEncoder) write(c *component) error {
+	if _, err := io.WriteString(enc.w, xml.Header); err != nil {
+		return err
+	}
+
+	x := xml.NewEncoder(enc.w)
+	root := xml.StartElement{Name: xml.Name{Local: "icalendar"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xcalNamespace}}}
+
+	if err := x.EncodeToken(root); err != nil {
+		return err
+	}
+	if err := writeXCalComponent(x, c); err != nil {
+		return err
+	}
+	if err := x.EncodeToken(root.End()); err != nil {
+		return err
+	}
+
+	return x.Flush()
+}
+
+// NewXCalParser creates a parser reading an xCal document. Its properties go
+// through the same handling as iCalendar feeds, so that the resulting events
+// are identical, under the same strict and duplicate modes.
+func NewXCalParser(r io.Reader) *Gocal {
+	gc := NewParser(r)
+	gc.decode = func() ([]*Line, error) {
+		return decodeXCal(r)
+	}
+
+	return gc
+}
+
+func writeXCalElement(x *xml.Encoder, name, text string) error {
+	return x.EncodeElement(text, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// writeXCalWrapped writes an element made of the given children.
+func writeXCalWrapped(x *xml.Encoder, name string, children func() error) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := x.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := children(); err != nil {
+		return err
+	}
+
+	return x.EncodeToken(start.End())
+}
+
+func writeXCalComponent(x *xml.Encoder, c *component) error {
+	return writeXCalWrapped(x, strings.ToLower(c.Name), func() error {
+		err := writeXCalWrapped(x, "properties", func() error {
+			for _, l := range c.Properties {
+				if err := writeXCalProperty(x, toProperty(l)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil || len(c.Components) == 0 {
+			return err
+		}
+
+		return writeXCalWrapped(x, "components", func() error {
+			for _, sub := range c.Components {
+				if err := writeXCalComponent(x, sub); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func writeXCalProperty(x *xml.Encoder, p property) error {
+	return writeXCalWrapped(x, p.Name, func() error {
+		if len(p.Params) > 0 {
+			keys := make([]string, 0, len(p.Params))
+			for key := range p.Params {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			err := writeXCalWrapped(x, "parameters", func() error {
+				for _, key := range keys {
+					ty := xcalParamTypes[key]
+					if ty == "" {
+						ty = "text"
+					}
+
+					err := writeXCalWrapped(x, key, func() error {
+						return writeXCalElement(x, ty, p.Params[key])
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case p.Type == "recur":
+			return writeXCalWrapped(x, "recur", func() error {
+				for _, part := range p.Recur {
+					for _, v := range part.Values {
+						if err := writeXCalElement(x, part.Name, v); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			})
+		case p.Name == "geo" && len(p.Values) == 2:
+			if err := writeXCalElement(x, "latitude", p.Values[0]); err != nil {
+				return err
+			}
+			return writeXCalElement(x, "longitude", p.Values[1])
+		case p.Type == "period":
+			for _, v := range p.Values {
+				err := writeXCalWrapped(x, "period", func() error {
+					tokens := strings.SplitN(v, "/", 2)
+					if err := writeXCalElement(x, "start", tokens[0]); err != nil {
+						return err
+					}
+					if len(tokens) == 2 && isDuration(tokens[1]) {
+						return writeXCalElement(x, "duration", tokens[1])
+					}
+					if len(tokens) == 2 {
+						return writeXCalElement(x, "end", tokens[1])
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, v := range p.Values {
+			if err := writeXCalElement(x, p.Type, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// xcalNode is an element of an xCal document, decoded without its namespace.
+type xcalNode struct {
+	Name     string
+	Text     string
+	Children []*xcalNode
+}
+
+func (n *xcalNode) child(name string) *xcalNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func decodeXCalNode(dec *xml.Decoder, start xml.StartElement) (*xcalNode, error) {
+	n := &xcalNode{Name: start.Name.Local}
+
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			c, err := decodeXCalNode(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, c)
+		case xml.CharData:
+			n.Text += string(t)
+		case xml.EndElement:
+			return n, nil
+		}
+	}
+}
+
+func decodeXCal(r io.Reader) ([]*Line, error) {
+	dec := xml.NewDecoder(r)
+
+	var root *xcalNode
+	for root == nil {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode xCal: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			if root, err = decodeXCalNode(dec, start); err != nil {
+				return nil, fmt.Errorf("could not decode xCal: %w", err)
+			}
+		}
+	}
+
+	if root.Name != "icalendar" {
+		return nil, fmt.Errorf("could not decode xCal: unexpected root element %s", root.Name)
+	}
+
+	lines := make([]*Line, 0)
+	for _, c := range root.Children {
+		decodeXCalComponent(c, &lines)
+	}
+
+	return lines, nil
+}
+
+func decodeXCalComponent(n *xcalNode, lines *[]*Line) {
+	name := strings.ToUpper(n.Name)
+	*lines = append(*lines, &Line{Key: "BEGIN", Value: name})
+
+	if props := n.child("properties"); props != nil {
+		for _, prop := range props.Children {
+			*lines = append(*lines, fromProperty(decodeXCalProperty(prop)))
+		}
+	}
+
+	if comps := n.child("components"); comps != nil {
+		for _, sub := range comps.Children {
+			decodeXCalComponent(sub, lines)
+		}
+	}
+
+	*lines = append(*lines, &Line{Key: "END", Value: name})
+}
+
+func decodeXCalProperty(n *xcalNode) property {
+	p := property{Name: n.Name, Params: make(map[string]string), Type: "unknown"}
+
+	for _, c := range n.Children {
+		switch {
+		case c.Name == "parameters":
+			for _, param := range c.Children {
+				values := make([]string, 0, len(param.Children))
+				for _, v := range param.Children {
+					values = append(values, v.Text)
+				}
+				p.Params[param.Name] = strings.Join(values, ",")
+			}
+		case c.Name == "recur":
+			p.Type = "recur"
+			for _, part := range c.Children {
+				if len(p.Recur) > 0 && p.Recur[len(p.Recur)-1].Name == part.Name {
+					last := &p.Recur[len(p.Recur)-1]
+					last.Values = append(last.Values, part.Text)
+					continue
+				}
+				p.Recur = append(p.Recur, recurPart{Name: part.Name, Values: []string{part.Text}})
+			}
+		case c.Name == "latitude" || c.Name == "longitude":
+			p.Type = "float"
+			p.Values = append(p.Values, c.Text)
+		case c.Name == "period":
+			p.Type = "period"
+			value := ""
+			if start := c.child("start"); start != nil {
+				value = start.Text
+			}
+			if end := c.child("end"); end != nil {
+				value += "/" + end.Text
+			} else if duration := c.child("duration"); duration != nil {
+				value += "/" + duration.Text
+			}
+			p.Values = append(p.Values, value)
+		default:
+			p.Type = c.Name
+			p.Values = append(p.Values, c.Text)
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,129 @@
+package gocal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_XCalRoundTrip(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewXCalEncoder(&out).Encode(gc))
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	rgc := NewXCalParser(&out)
+	rgc.Start, rgc.End = &start, &end
+	assert.Nil(t, rgc.Parse())
+
+	for idx := range rgc.Events {
+		rgc.Events[idx].delayed = nil
+	}
+	for idx := range rgc.Todos {
+		rgc.Todos[idx].delayed = nil
+	}
+
+	// xCal parameters are not quoted, quotes are only restored where needed
+	gc.Events[0].Attendees[0].CustomAttributes["X-RESPONSE-COMMENT"] = "Not interested"
+
+	assert.Equal(t, gc.Method, rgc.Method)
+	assert.Equal(t, gc.Events, rgc.Events)
+	assert.Equal(t, gc.Todos, rgc.Todos)
+	assert.Equal(t, gc.Journals, rgc.Journals)
+	assert.Equal(t, gc.Timezones["Custom Zone"].Observances, rgc.Timezones["Custom Zone"].Observances)
+}
+
+func Test_XCalEncodeEvent(t *testing.T) {
+	gc := parseForEncoding(t, encoderICS)
+
+	var out bytes.Buffer
+	assert.Nil(t, NewXCalEncoder(&out).EncodeEvent(*gc.Events[2].Series.Master))
+
+	assert.Contains(t, out.String(), `<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0"><vcalendar><properties>`+
+		`<version><text>2.0</text></version><prodid><text>-//apognu//gocal//EN</text></prodid></properties>`+
+		`<components><vevent><properties>`)
+	assert.True(t, strings.HasSuffix(out.String(), `</vevent></components></vcalendar></icalendar>`))
+	assert.Contains(t, out.String(), `<dtstart><parameters><tzid><text>Custom Zone</text></tzid></parameters><date-time>2024-01-08T09:00:00</date-time></dtstart>`)
+	assert.Contains(t, out.String(), `<rrule><recur><freq>WEEKLY</freq><byday>MO</byday></recur></rrule>`)
+}
+
+func Test_XCalParse(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="utf-8"?>
+<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+  <vcalendar>
+    <properties>
+      <version><text>2.0</text></version>
+    </properties>
+    <components>
+      <vevent>
+        <properties>
+          <uid><text>xcal@gocal</text></uid>
+          <dtstamp><date-time>2024-01-01T00:00:00Z</date-time></dtstamp>
+          <dtstart>
+            <parameters><tzid><text>Europe/Paris</text></tzid></parameters>
+            <date-time>2024-01-05T09:00:00</date-time>
+          </dtstart>
+          <duration><duration>PT1H</duration></duration>
+          <summary><text>Comma, semicolon; and backslash \</text></summary>
+          <geo><latitude>48.85</latitude><longitude>2.35</longitude></geo>
+          <rrule><recur><freq>WEEKLY</freq><byday>MO</byday><byday>FR</byday><count>3</count></recur></rrule>
+        </properties>
+      </vevent>
+    </components>
+  </vcalendar>
+</icalendar>`
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewXCalParser(strings.NewReader(doc))
+	gc.Start, gc.End = &start, &end
+
+	assert.Nil(t, gc.Parse())
+	assert.Len(t, gc.Events, 3)
+
+	tz, _ := time.LoadLocation("Europe/Paris")
+
+	e := gc.Events[0]
+	assert.Equal(t, "xcal@gocal", e.Uid)
+	assert.Equal(t, time.Date(2024, 1, 5, 9, 0, 0, 0, tz), *e.Start)
+	assert.Equal(t, time.Date(2024, 1, 5, 10, 0, 0, 0, tz), *e.End)
+	assert.Equal(t, "Comma, semicolon; and backslash \\", e.Summary)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", e.RecurrenceRuleString)
+	assert.Equal(t, 2.35, e.Geo.Long)
+}
+
+func Test_XCalDuplicateAttributes(t *testing.T) {
+	doc := `<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0"><vcalendar><components><vevent><properties>
+  <uid><text>xcal@gocal</text></uid>
+  <dtstamp><date-time>2024-01-01T00:00:00Z</date-time></dtstamp>
+  <dtstart><date-time>2024-01-05T09:00:00Z</date-time></dtstart>
+  <dtend><date-time>2024-01-05T10:00:00Z</date-time></dtend>
+  <summary><text>First</text></summary>
+  <summary><text>Second</text></summary>
+</properties></vevent></components></vcalendar></icalendar>`
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	gc := NewXCalParser(strings.NewReader(doc))
+	gc.Start, gc.End = &start, &end
+
+	assert.NotNil(t, gc.Parse())
+
+	gc = NewXCalParser(strings.NewReader(doc))
+	gc.Start, gc.End = &start, &end
+	gc.Duplicate.Mode = DuplicateModeKeepLast
+
+	assert.Nil(t, gc.Parse())
+	assert.Equal(t, "Second", gc.Events[0].Summary)
+}
+
+func Test_XCalInvalid(t *testing.T) {
+	gc := NewXCalParser(strings.NewReader(`<vcalendar></vcalendar>`))
+
+	assert.NotNil(t, gc.Parse())
+}